@@ -23,9 +23,27 @@ def EnumPureParticles(v_lo, v_hi):
 def NewGraph(*parts):
     return Graph(*parts)
 
+def TracesNormalizers():
+    """Returns a dict of the available Traces normalizer names and their descriptions"""
+    return _py2x3.TracesNormalizers()
+
+def Traces(*terms):
+    """
+    Returns a Traces from a str (e.g. "5,13,35"), a tuple or list of ints, or the given ints.
+    Traces support +, -, * (by an int), unary -, comparisons, len() and indexing, e.g.  Traces(5,13,35) - proton.Traces()
+    """
+    if len(terms) == 1 and isinstance(terms[0], Graph):
+        return terms[0].Traces()
+    return _py2x3.Traces(*terms)
+
 class Graph:
 
     def __init__(self, *parts):
+        """
+        Forms a Graph from the given parts, where each part is a Graph or a str that is either a graph expression (e.g. "1-2-3"),
+        an op expression, e.g. "mirror(1-2-3)", "replace(3, tetra)", "sum(p+, e-)", or "power(4:2, 1=2)",
+        or a hex op-string or enumeration ID (see Encode).
+        """
         self._graph = _py2x3.NewGraph()
         self.Concat(*parts)
         
@@ -39,16 +57,34 @@ class Graph:
         return self._graph.NumParts()
         
     def Traces(self, num_traces = 0):
+        """Returns this graph's Traces (see Traces), raising OverflowError if they overflow an int64 (see ExactTraces)"""
         return self._graph.Traces(num_traces)
 
+    def ExactTraces(self, num_traces = 0):
+        """Returns this graph's Traces as a tuple of exact ints, which never overflow"""
+        return self._graph.ExactTraces(num_traces)
+
     def Concat(self, *parts):
         self._graph.Concat(parts)
 
     def Stream(self):
         return self._graph.Stream()
 
-    def Canonize(self, normalize = False):
-        return self._graph.Stream().Canonize(normalize)
+    def Encode(self, form = ""):
+        """Returns this graph encoded in the given form: "GraphExpr", "BinaryOpString" or "EnumerationID" ("" picks the first that applies).
+
+        Binary forms are returned in hex, and any of these forms can be passed back to Graph().
+        """
+        return self._graph.Encode(form)
+
+    def Canonize(self, normalize = False, **kwargs):
+        """Canonizes each Graph from a GraphStream
+
+        Available kwargs:
+            workers = int           - Number of goroutines to canonize with (-1 denotes one per CPU)
+            ordered = bool          - If False, graphs are emitted as soon as they are ready (default: True)
+        """
+        return self._graph.Stream().Canonize(normalize, **kwargs)
 
     def Print(self, *args, **kwargs):
         """Prints each Graph from a GraphStream with various options
@@ -57,11 +93,62 @@ class Graph:
             label = string          - Sets a label for this output run 
             traces = int            - Prints the graph's first N Traces (N=0 denotes the vertex count)
             cycles = bool           - Prints cycle computation details
+            primitive = bool        - Prints primitive cycle details (each column only counts cycles that don't repeat a shorter cycle)
+            edges = bool            - Prints edge group details (edges grouped by the cycles they contribute)
+            normalize = str|list    - Appends the graph's Traces normalized by each named normalizer (see TracesNormalizers)
             uid = bool              - Prints the graph's canonic UID 
             file = <pathname>       - Echos output to the given file pathname 
         """
         return self._graph.Stream().Print(*args, **kwargs)
 
+    def EdgeGroupDual(self):
+        """Returns a GraphStream holding this graph's edge group dual, where each group of edges having the same cycles becomes a vertex 
+        and two groups are joined by an edge weighted by the number of vertices they share"""
+        return self._graph.Stream().EdgeGroupDual()
+
+    def PrimitiveTraces(self, num_traces = 0):
+        """Returns this graph's primitive Traces, where Pk counts the closed walks of length k that are not a repeat of a shorter closed walk"""
+        return self._graph.PrimitiveTraces(num_traces)
+
+    def NonBacktrackingTraces(self, num_traces = 0):
+        """Returns this graph's non-backtracking Traces, where Ti counts the closed walks of length i that never immediately reverse an edge"""
+        return self._graph.NonBacktrackingTraces(num_traces)
+
+    def WalkMatrices(self, num_traces = 0, groups = False, format = ""):
+        """Returns this graph's walk-count matrices A^1 .. A^N, where walks[i][j] of matrix k counts the walks of length k from j to i.
+
+        If groups is True, rows and columns are summed by consolidated vertex group (vertices having the same cycles).
+        Returns a list of dicts (keyed by "k", "labels" and "walks"), or a string if format is "csv" or "json".
+        """
+        return self._graph.WalkMatrices(traces = num_traces, groups = groups, format = format)
+
+    def ExtendedTraces(self, num_traces):
+        """
+        Returns this graph's first N Traces, computing only its first Nv Traces directly and extending them by recurrence (see CharPoly).
+        Like Traces, raises OverflowError if they overflow an int64 (see ExactTraces).
+        """
+        return self._graph.ExtendedTraces(num_traces)
+
+    def CharPoly(self):
+        """Returns this graph's characteristic polynomial coefficients (1, C1, .. Cn) as a tuple of ints, computed exactly from its Traces"""
+        return self._graph.CharPoly()
+
+    def Spectrum(self):
+        """Returns this graph's adjacency eigenvalues as a tuple of floats in descending order"""
+        return self._graph.Spectrum()
+
+    def SpectralRadius(self):
+        """Returns the largest magnitude of this graph's adjacency eigenvalues"""
+        return self._graph.SpectralRadius()
+
+    def NormalizedTraces(self, normalizer, num_traces = 0):
+        """Returns this graph's first N Traces (N=0 denotes the vertex count) as a tuple of floats normalized by the named normalizer (see TracesNormalizers)"""
+        return self._graph.NormalizedTraces(normalizer, num_traces)
+
+    def PrintNormalizedTraces(self, *normalizers, traces = 0):
+        """Prints this graph's Traces alongside each of the named normalizations (or all normalizers if none are given)"""
+        self._graph.PrintNormalizedTraces(*normalizers, traces = traces)
+
     def AddTo(self, catalog):
         return self._graph.Stream().AddTo(catalog)
         
@@ -120,6 +207,7 @@ class GraphSelector:
     """
 
     def __init__(self, traces = None):
+        """traces may be a Traces, a Graph, or any str accepted by Graph() (e.g. "1-2-3" or an enumeration ID)"""
         self.min = GraphInfo()
         self.max = GraphInfo()
         self.Init()
@@ -131,6 +219,8 @@ class GraphSelector:
         self.select_primes = False
         self.select_bosons = False
         self.unique_traces = False
+        self.primes = None          # prime Catalog used to test graphs when selecting primes from a GraphStream
+        self.predicates = []        # Traces query terms each selected graph must meet, e.g. "T1 == 0", "T2 % 9 == 0", "odd == 0"
 
         self.min.parts = 1
         self.min.verts = 1
@@ -148,6 +238,13 @@ class GraphSelector:
         
     def SetTraces(self, X):
         self.traces = X.Traces()
+
+    def Where(self, *terms):
+        """
+        Adds the given Traces query terms (e.g. "T1 == 0", "T3 < 0", "T2 % 9 == 0", "!boson") to this selector's predicates.
+        """
+        self.predicates.extend(terms)
+        return self
     
     
 # class GraphOutputParams:
@@ -214,6 +311,7 @@ def ShowPhases(desc, Xstr, verbose = False):
 READ_ONLY           = 0x01
 READ_WRITE          = 0x02
 PRIME_CATALOG       = 0x04
+INDEX_NON_BACKTRACKING = 0x08   # new catalog indexes graphs by their non-backtracking Traces
 
 def NewCatalog(pathname = "", flags = READ_WRITE):
     return Catalog(pathname, flags)
@@ -231,6 +329,9 @@ class Catalog:
         self._cat = _py2x3.GetWorkspace().OpenCatalog(pathname, flags, minTraceCount)
         
     def Select(self, graph_selector = None):
+        """
+        Selects graphs from this Catalog using a GraphSelector or a query string, e.g. "v in 4..8 && prime && T1==5 && T2>=13"
+        """
 
         # If no selector is given, use this Catalog's default GraphSelector
         if graph_selector == None:
@@ -1,6 +1,6 @@
 module github.com/fine-structures/fine.SDK
 
-go 1.22
+go 1.23
 
 // List versions of a module:
 //    go list -m -versions github.com/art-media-platform/amp.SDK
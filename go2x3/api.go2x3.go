@@ -1,6 +1,7 @@
 package go2x3
 
 import (
+	"context"
 	"errors"
 	"io"
 
@@ -36,6 +37,8 @@ const (
 type State interface {
 	TracesProvider
 
+	// PermuteEdgeSigns and PermuteVtxSigns send each sign permutation of this graph to dst.
+	// They return early if dst is cancelled and report any failure via dst.Fail().
 	PermuteEdgeSigns(dst *GraphStream)
 	PermuteVtxSigns(dst *GraphStream)
 
@@ -57,9 +60,27 @@ type State interface {
 
 type TracesProvider interface {
 	VertexCount() int
+
+	// Traces returns the first numTraces Traces (0 denotes VertexCount() Traces).
+	// A provider that stores rather than computes its Traces (such as Traces itself) may return fewer than requested.
 	Traces(numTraces int) Traces
 }
 
+// GraphExprProvider is optionally implemented by a State that can express itself as a human-readable graph expression (e.g. "1-2-3-1").
+type GraphExprProvider interface {
+
+	// AppendGraphExpr appends this graph's expression to the given buffer.
+	AppendGraphExpr(out []byte) []byte
+}
+
+// EdgeGroupDualProvider is optionally implemented by a State that can form its "edge group dual": a graph whose vertices are
+// the groups of edges having the same cycles and whose edges are weighted by the number of vertices two groups share.
+type EdgeGroupDualProvider interface {
+
+	// EdgeGroupDual returns a new State holding this graph's edge group dual.
+	EdgeGroupDual() (State, error)
+}
+
 // Traces is a sequence of a 2x3 graph "traces" values (successive powers of the graph adjacency matrix).
 type Traces []int64
 
@@ -91,12 +112,21 @@ type CatalogContext interface {
 
 // CatalogOpts specifies params for opening a lib2x3 Catalog
 type CatalogOpts struct {
-	DbPathName string // omit or for in-memory db
-	ReadOnly   bool   // open in read-only mode
-	TraceCount int32  // number of traces to preallocate
-	NeedPrimes bool   // set if this catalog will be used for prime detection
+	DbPathName string      // omit or for in-memory db
+	ReadOnly   bool        // open in read-only mode
+	TraceCount int32       // number of traces to preallocate
+	NeedPrimes bool        // set if this catalog will be used for prime detection
+	IndexBy    TracesIndex // Traces this catalog indexes graphs by (set when a catalog is created)
 }
 
+// TracesIndex specifies which Traces a Catalog indexes (and so groups) its graphs by.
+type TracesIndex byte
+
+const (
+	IndexByTraces          TracesIndex = iota // closed-walk Traces (the default)
+	IndexByNonBacktracking                    // non-backtracking Traces (see NonBacktrackingProvider)
+)
+
 type GraphAdder interface {
 
 	// Tries to add the given graph encoding to this catalog.
@@ -120,7 +150,9 @@ type Catalog interface {
 	NumPrimes(forVtxCount byte) int64
 
 	// Select fires the given callback with each GraphEncoding that meets the selection criteria.
-	Select(sel GraphSelector, onHit OnStateHit)
+	// Selection stops early once ctx is done, in which case any State not yet sent is reclaimed.
+	// If an entry can't be read or decoded, selection stops and the error is returned.
+	Select(ctx context.Context, sel GraphSelector, onHit OnStateHit) error
 
 	Close() error
 }
@@ -136,23 +168,38 @@ type GraphInfo struct {
 }
 
 // GraphSelector is an operator that either selects a given Graph or not.
+//
+// A selector behaves the same on a Catalog and on a GraphStream, except that Factor is only supported by a Catalog
+// and selecting primes from a stream requires Primes to be set.
 type GraphSelector struct {
-	Traces       TracesProvider // Implies a Traces to match with or factor
-	Factor       bool           // Perform factorization of sel.Traces
-	UniqueTraces bool           // Only select the first Graph for each unique traces
-	SelectPrimes bool           // Select only prime graphs
-	SelectBosons bool           // Select only boson graphs
-	Min          GraphInfo      // lower select bounds
-	Max          GraphInfo      // upper select bounds
+	Traces       TracesProvider    // Implies a Traces to match with or factor
+	Factor       bool              // Perform factorization of sel.Traces
+	UniqueTraces bool              // Only select the first Graph for each unique traces
+	SelectPrimes bool              // Select only prime graphs
+	SelectBosons bool              // Select only boson graphs (all odd traces are zero)
+	Min          GraphInfo         // lower select bounds
+	Max          GraphInfo         // upper select bounds
+	Primes       PrimeTester       // prime tests graphs when selecting primes from a stream
+	Predicates   []TracesPredicate // each selected graph's Traces must meet every predicate
+}
+
+// PrimeTester tests if a graph is prime, i.e. if its traces are not the sum of the traces of smaller graphs.
+type PrimeTester interface {
+
+	// IsPrime returns true if the given Traces (of length Nv, the graph's vertex count) has no factorization.
+	IsPrime(TX Traces) bool
 }
 
 // PrintOpts specifies what is printing when printing a graph
 type PrintOpts struct {
-	Label     string // Prefix label
-	Graph     bool   // If set, prints graph construction expr
-	Matrix    bool   // if set, prints matrix representation of graph
-	NumTraces int    // Num of Traces to print (-1 denotes natural length, 0 denotes no traces)
-	CycleSpec bool   // If set, the cycles spectrum is printed -- i.e. a canonic column of "cycles" vectors
+	Label     string   // Prefix label
+	Graph     bool     // If set, prints graph construction expr
+	Matrix    bool     // if set, prints matrix representation of graph
+	NumTraces int      // Num of Traces to print (-1 denotes natural length, 0 denotes no traces)
+	CycleSpec bool     // If set, the cycles spectrum is printed -- i.e. a canonic column of "cycles" vectors
+	Normalize []string // Names of TracesNormalizers whose normalized Traces are appended (see RegisterNormalizer)
+	Primitive bool     // If set, the primitive cycles spectrum is also printed (see Traces.Primitive)
+	EdgeGroup bool     // If set, the edge group spectrum is printed -- i.e. the cycles vector of each group of edges having the same cycles
 }
 
 // DefaultPrintOpts{}
@@ -188,6 +235,7 @@ type FactorRun struct {
 var (
 	ErrUnmarshal          = errors.New("unmarshal failed")
 	ErrBadCatalogParam    = errors.New("bad catalog param")
+	ErrBadStreamParam     = errors.New("bad graph stream param")
 	ErrInsufficientTraces = errors.New("insufficient traces")
 	ErrBadEncoding        = errors.New("bad graph encoding")
 	ErrBadVtxID           = errors.New("bad graph vertex ID")
@@ -200,4 +248,13 @@ var (
 	ErrSitesExceeded      = errors.New("number of loops and edges exceeds 3")
 	ErrNilGraph           = errors.New("nil graph")
 	ErrInvalidVtxID       = errors.New("invalid vertex or group ID")
+	ErrNotSupported       = errors.New("operation not supported")
+	ErrFactorOnStream     = errors.New("factor selection is only supported by a catalog")
+	ErrNoPrimeTester      = errors.New("selecting primes from a stream requires GraphSelector.Primes")
+	ErrBadQuery           = errors.New("bad graph query")
+	ErrTracesOverflow     = errors.New("traces overflow int64")
+	ErrUnknownNormalizer  = errors.New("unknown traces normalizer")
+	ErrNotCharPoly        = errors.New("traces are not those of an integer matrix")
+	ErrInexactSpectrum    = errors.New("spectrum is not real or could not be computed within tolerance")
+	ErrBadOpNode          = errors.New("bad graph op node")
 )
@@ -0,0 +1,249 @@
+package go2x3
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+// AggregateField is a per-graph value that an Aggregation can group by.
+type AggregateField int32
+
+const (
+	ByNumParticles AggregateField = iota
+	ByNumVertex
+	ByNegLoops
+	ByPosLoops
+	ByNegEdges
+	ByPosEdges
+	ByIsPrime
+	ByTraces // groups by the graph's first AggregateOpts.NumTraces Traces
+)
+
+// AggregateFieldNames are the column names of each AggregateField, matching the GraphInfo attribute names in py2x3.py.
+var AggregateFieldNames = []string{
+	ByNumParticles: "parts",
+	ByNumVertex:    "verts",
+	ByNegLoops:     "neg_loops",
+	ByPosLoops:     "pos_loops",
+	ByNegEdges:     "neg_edges",
+	ByPosEdges:     "pos_edges",
+	ByIsPrime:      "is_prime",
+	ByTraces:       "traces",
+}
+
+// AggregateFieldByName returns the AggregateField having the given name (see AggregateFieldNames).
+func AggregateFieldByName(name string) (AggregateField, bool) {
+	idx := slices.Index(AggregateFieldNames, name)
+	return AggregateField(idx), idx >= 0
+}
+
+func (field AggregateField) String() string {
+	if field < 0 || int(field) >= len(AggregateFieldNames) {
+		return fmt.Sprintf("AggregateField(%d)", int32(field))
+	}
+	return AggregateFieldNames[field]
+}
+
+// AggregateOpts specifies how an Aggregation groups and tallies graphs.
+type AggregateOpts struct {
+	GroupBy        []AggregateField // fields forming each row (in column order); none denotes a single row
+	NumTraces      int              // number of Traces used by ByTraces and DistinctTraces (0 denotes the vertex count)
+	DistinctTraces bool             // if set, the number of distinct Traces within each row is also tallied
+}
+
+// AggregateRow is a tally of graphs sharing the same GroupBy values.
+type AggregateRow struct {
+	Key            []int64 // value of each GroupBy field (ByTraces has the value 0 here)
+	Traces         Traces  // the row's Traces if grouped ByTraces, otherwise nil
+	Count          int64   // number of graphs
+	DistinctTraces int64   // number of distinct Traces (if AggregateOpts.DistinctTraces is set)
+
+	sortKey []byte
+}
+
+// Aggregation holds the tallies accumulated by GraphStream.Aggregate.
+type Aggregation struct {
+	Opts AggregateOpts
+
+	mu     sync.Mutex
+	rows   map[string]*AggregateRow
+	traces map[string]struct{} // row key + traces key, for DistinctTraces
+	done   chan struct{}
+}
+
+func newAggregation(opts AggregateOpts) *Aggregation {
+	return &Aggregation{
+		Opts:   opts,
+		rows:   make(map[string]*AggregateRow),
+		traces: make(map[string]struct{}),
+		done:   make(chan struct{}),
+	}
+}
+
+// Aggregate tallies each State passing through this stream (which is otherwise unchanged).
+//
+// The returned Aggregation is complete once the returned stream has closed (see Aggregation.Done).
+func (stream *GraphStream) Aggregate(ctx context.Context, opts AggregateOpts) (*GraphStream, *Aggregation) {
+	agg := newAggregation(opts)
+	next := stream.startStage(ctx, func(X State, next *GraphStream) bool {
+		agg.Add(X)
+		return next.Send(X)
+	}, func() {
+		close(agg.done)
+	})
+	return next, agg
+}
+
+// Done is closed once the stage feeding this Aggregation has finished.
+func (agg *Aggregation) Done() <-chan struct{} {
+	return agg.done
+}
+
+// Add tallies the given graph.
+func (agg *Aggregation) Add(X State) {
+	info := X.GraphInfo()
+
+	var keyBuf, tracesBuf [256]byte
+	key := keyBuf[:0]
+	var TX Traces
+	if agg.Opts.DistinctTraces || slices.Contains(agg.Opts.GroupBy, ByTraces) {
+		TX = X.Traces(agg.Opts.NumTraces)
+	}
+
+	for _, field := range agg.Opts.GroupBy {
+		switch field {
+		case ByTraces:
+			key = append(key, byte(len(TX)))
+			key = TX.AppendTracesLSM(key)
+		default:
+			key = append(key, byte(info.fieldValue(field)))
+		}
+	}
+
+	agg.mu.Lock()
+	defer agg.mu.Unlock()
+
+	row := agg.rows[string(key)]
+	if row == nil {
+		row = &AggregateRow{
+			Key:     make([]int64, len(agg.Opts.GroupBy)),
+			sortKey: append([]byte{}, key...),
+		}
+		for i, field := range agg.Opts.GroupBy {
+			if field == ByTraces {
+				row.Traces = append(Traces{}, TX...)
+			} else {
+				row.Key[i] = info.fieldValue(field)
+			}
+		}
+		agg.rows[string(key)] = row
+	}
+	row.Count++
+
+	if agg.Opts.DistinctTraces {
+		tracesKey := append(append(tracesBuf[:0], key...), 0xFF)
+		tracesKey = TX.AppendTracesLSM(tracesKey)
+		if _, seen := agg.traces[string(tracesKey)]; !seen {
+			agg.traces[string(tracesKey)] = struct{}{}
+			row.DistinctTraces++
+		}
+	}
+}
+
+func (info *GraphInfo) fieldValue(field AggregateField) int64 {
+	switch field {
+	case ByNumParticles:
+		return int64(info.NumParticles)
+	case ByNumVertex:
+		return int64(info.NumVertex)
+	case ByNegLoops:
+		return int64(info.NegLoops)
+	case ByPosLoops:
+		return int64(info.PosLoops)
+	case ByNegEdges:
+		return int64(info.NegEdges)
+	case ByPosEdges:
+		return int64(info.PosEdges)
+	case ByIsPrime:
+		return int64(info.IsPrime)
+	}
+	return 0
+}
+
+// Rows returns a copy of the tallied rows, ordered by their GroupBy values.
+func (agg *Aggregation) Rows() []AggregateRow {
+	agg.mu.Lock()
+	rows := make([]AggregateRow, 0, len(agg.rows))
+	for _, row := range agg.rows {
+		rows = append(rows, *row)
+	}
+	agg.mu.Unlock()
+
+	slices.SortFunc(rows, func(a, b AggregateRow) int {
+		return bytes.Compare(a.sortKey, b.sortKey)
+	})
+	return rows
+}
+
+// WriteTable writes the tallied rows as a right-aligned text table with a header line.
+func (agg *Aggregation) WriteTable(out io.Writer) error {
+	cols := make([]string, 0, len(agg.Opts.GroupBy)+2)
+	for _, field := range agg.Opts.GroupBy {
+		cols = append(cols, field.String())
+	}
+	cols = append(cols, "count")
+	if agg.Opts.DistinctTraces {
+		cols = append(cols, "distinct")
+	}
+
+	rows := agg.Rows()
+	cells := make([][]string, len(rows))
+	widths := make([]int, len(cols))
+	for i, col := range cols {
+		widths[i] = max(len(col), 6)
+	}
+	for ri, row := range rows {
+		line := make([]string, 0, len(cols))
+		for i, field := range agg.Opts.GroupBy {
+			if field == ByTraces {
+				var tracesStr []byte
+				for i, TXi := range row.Traces {
+					if i > 0 {
+						tracesStr = append(tracesStr, ',')
+					}
+					tracesStr = strconv.AppendInt(tracesStr, TXi, 10)
+				}
+				line = append(line, string(tracesStr))
+			} else {
+				line = append(line, fmt.Sprint(row.Key[i]))
+			}
+		}
+		line = append(line, fmt.Sprint(row.Count))
+		if agg.Opts.DistinctTraces {
+			line = append(line, fmt.Sprint(row.DistinctTraces))
+		}
+		for i, cell := range line {
+			widths[i] = max(widths[i], len(cell))
+		}
+		cells[ri] = line
+	}
+
+	var buf bytes.Buffer
+	writeLine := func(line []string) {
+		for i, cell := range line {
+			fmt.Fprintf(&buf, "  %*s", widths[i], cell)
+		}
+		buf.WriteByte('\n')
+	}
+	writeLine(cols)
+	for _, line := range cells {
+		writeLine(line)
+	}
+	_, err := out.Write(buf.Bytes())
+	return err
+}
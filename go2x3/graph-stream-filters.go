@@ -0,0 +1,101 @@
+package go2x3
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+)
+
+// StateKeyFunc appends a key identifying X to the given buffer.  Two States having the same key are considered duplicates.
+type StateKeyFunc func(X State, key []byte) []byte
+
+// KeyByTraces keys a State by its first numTraces Traces (0 denotes the vertex count).
+func KeyByTraces(numTraces int) StateKeyFunc {
+	return func(X State, key []byte) []byte {
+		key = append(key, byte(X.VertexCount()))
+		return X.Traces(numTraces).AppendTracesLSM(key)
+	}
+}
+
+// KeyByState keys a State by its Traces and state encoding (MarshalOpts.AsState), matching lib2x3.NewDropDupes.
+func KeyByState(X State, key []byte) []byte {
+	key = X.Traces(0).AppendTracesLSM(key)
+	if withState, err := X.MarshalOut(key, AsState); err == nil {
+		key = withState
+	}
+	return key
+}
+
+// KeyByGraphExpr keys a State by its graph expression (see GraphExprProvider).
+func KeyByGraphExpr(X State, key []byte) []byte {
+	if provider, ok := X.(GraphExprProvider); ok {
+		key = provider.AppendGraphExpr(key)
+	}
+	return key
+}
+
+// Limit emits the first n States of this stream and then cancels this stream, stopping all upstream work.
+//
+// If n <= 0, the returned stream is already closed.
+func (stream *GraphStream) Limit(ctx context.Context, n int) *GraphStream {
+	if n <= 0 {
+		return stream.closedStage(ctx, nil)
+	}
+	count := 0
+	return stream.startStage(ctx, func(X State, next *GraphStream) bool {
+		count++
+		if count > n {
+			X.Reclaim()
+			return false
+		}
+		return next.Send(X) && count < n
+	}, nil)
+}
+
+// Skip drops the first n States of this stream and emits the rest.
+func (stream *GraphStream) Skip(ctx context.Context, n int) *GraphStream {
+	count := 0
+	return stream.startStage(ctx, func(X State, next *GraphStream) bool {
+		count++
+		if count <= n {
+			X.Reclaim()
+			return true
+		}
+		return next.Send(X)
+	}, nil)
+}
+
+// Sample emits each State of this stream with the given probability (0..1).
+// The same seed over the same stream yields the same sample.
+//
+// If rate is outside 0..1, the returned stream fails with ErrBadStreamParam.
+func (stream *GraphStream) Sample(ctx context.Context, rate float64, seed uint64) *GraphStream {
+	if !(rate >= 0 && rate <= 1) {
+		return stream.closedStage(ctx, fmt.Errorf("%w: Sample rate must be within 0..1 (got %v)", ErrBadStreamParam, rate))
+	}
+	rng := rand.New(rand.NewPCG(seed, seed^0x2C3A_D1E5_7F0B_964D))
+	return stream.startStage(ctx, func(X State, next *GraphStream) bool {
+		if rng.Float64() >= rate {
+			X.Reclaim()
+			return true
+		}
+		return next.Send(X)
+	}, nil)
+}
+
+// DedupeBy emits only the first State of this stream for each key returned by keyFunc.
+//
+// Keys are retained for the life of the stage, so memory grows with the number of unique keys.
+func (stream *GraphStream) DedupeBy(ctx context.Context, keyFunc StateKeyFunc) *GraphStream {
+	seen := make(map[string]struct{})
+	var keyBuf [512]byte
+	return stream.startStage(ctx, func(X State, next *GraphStream) bool {
+		key := keyFunc(X, keyBuf[:0])
+		if _, dupe := seen[string(key)]; dupe {
+			X.Reclaim()
+			return true
+		}
+		seen[string(key)] = struct{}{}
+		return next.Send(X)
+	}, nil)
+}
@@ -1,40 +1,184 @@
 package go2x3
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"iter"
+	"runtime"
+	"sync"
 )
 
+// GraphStream is a channel of States flowing from one pipeline stage to the next.
+//
+// Each State received from Outlet is owned by the receiver, who must either pass it on or Reclaim() it.
+// A stream is bound to a context: once the context is done (or Cancel() is called), the stage producing
+// the stream stops, reclaims any State it was holding, and cancels its own upstream.
+//
+// If a stage fails, it stops, cancels its upstream, and closes its stream early; Err() then reports the failure
+// to the consumer of that stream (or of any stream downstream of it).
 type GraphStream struct {
 	Outlet chan State
+
+	ctx      context.Context    // context this stream was created with
+	done     context.Context    // done when ctx is done or the stream is cancelled
+	cancel   context.CancelFunc // cancels done
+	upstream []*GraphStream     // streams feeding the stage that produces this stream (if any)
+	teed     bool               // if set, upstream is shared with other streams and so is not cancelled by Cancel (see Tee)
+	closing  sync.Once
+	cancels  sync.Once
+	errMu    sync.Mutex
+	err      error // first failure reported by the producer of this stream
+}
+
+// NewGraphStream returns a new (unbuffered) GraphStream bound to the given context.
+func NewGraphStream(ctx context.Context) *GraphStream {
+	return newGraphStream(ctx, 0)
 }
 
-func NewGraphStream() *GraphStream {
+func newGraphStream(ctx context.Context, bufSz int, upstream ...*GraphStream) *GraphStream {
+	if ctx == nil {
+		ctx = context.Background()
+	}
 	stream := &GraphStream{
-		Outlet: make(chan State),
+		Outlet:   make(chan State, bufSz),
+		ctx:      ctx,
+		upstream: upstream,
 	}
+	stream.done, stream.cancel = context.WithCancel(ctx)
 	return stream
 }
 
-func StreamGraph(X State) *GraphStream {
-	next := NewGraphStream()
+// StreamGraph returns a stream that emits a copy of X and then closes.
+func StreamGraph(ctx context.Context, X State) *GraphStream {
+	next := NewGraphStream(ctx)
 
 	go func() {
-		next.Outlet <- X.MakeCopy()
+		next.Send(X.MakeCopy())
 		next.Close()
 	}()
 
 	return next
 }
 
+// closedStage returns a stage of this stream that is already closed, failing with err (if non-nil) and cancelling this stream.
+func (stream *GraphStream) closedStage(ctx context.Context, err error) *GraphStream {
+	next := newGraphStream(ctx, 0, stream)
+	next.Fail(err)
+	next.Close()
+	stream.Cancel()
+	return next
+}
+
+// Context returns the context this stream was created with.
+func (stream *GraphStream) Context() context.Context {
+	if stream.ctx == nil {
+		return context.Background()
+	}
+	return stream.ctx
+}
+
+// Done returns a channel that is closed once this stream has been cancelled (or its context is done).
+func (stream *GraphStream) Done() <-chan struct{} {
+	if stream.done == nil {
+		return nil
+	}
+	return stream.done.Done()
+}
+
+// Send transfers ownership of X to the consumer of this stream.
+//
+// If the stream is cancelled before X is received, X is reclaimed and false is returned, signalling the producer to stop.
+func (stream *GraphStream) Send(X State) bool {
+	select {
+	case stream.Outlet <- X:
+		return true
+	case <-stream.Done():
+		X.Reclaim()
+		return false
+	}
+}
+
+// Close is called by the producer of this stream to signal that no more States will be sent.
+// It is safe to call more than once.
 func (stream *GraphStream) Close() {
-	if stream.Outlet != nil {
-		close(stream.Outlet)
+	stream.closing.Do(func() {
+		if stream.Outlet != nil {
+			close(stream.Outlet)
+		}
+		if stream.cancel != nil {
+			stream.cancel()
+		}
+	})
+}
+
+// Cancel is called by the consumer of this stream to signal that no more States are wanted.
+//
+// The stage producing this stream stops (as does everything upstream of it) and any States still in flight are reclaimed.
+// It is safe to call more than once.
+func (stream *GraphStream) Cancel() {
+	stream.cancels.Do(func() {
+		if stream.cancel != nil {
+			stream.cancel()
+		}
+		if !stream.teed {
+			for _, src := range stream.upstream {
+				src.Cancel()
+			}
+		}
+		go func() {
+			for X := range stream.Outlet {
+				X.Reclaim()
+			}
+		}()
+	})
+}
+
+// Fail is called by the producer of this stream to report a failure.  Only the first failure is retained.
+//
+// The producer should stop sending, Reclaim() whatever it holds, and Close() the stream.
+func (stream *GraphStream) Fail(err error) {
+	if err == nil {
+		return
+	}
+	stream.errMu.Lock()
+	if stream.err == nil {
+		stream.err = err
+	}
+	stream.errMu.Unlock()
+}
+
+// Err returns the first failure reported by this stream or by any stream upstream of it (or nil if none).
+//
+// Err should be checked once Outlet has closed since a failure ends a stream early.
+func (stream *GraphStream) Err() error {
+	stream.errMu.Lock()
+	err := stream.err
+	stream.errMu.Unlock()
+	if err != nil {
+		return err
+	}
+	for _, src := range stream.upstream {
+		if err = src.Err(); err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
-func (stream *GraphStream) PushGraph(X State) {
-	stream.Outlet <- X.MakeCopy()
+// isLive returns true if this stream has not been cancelled and its producer has not failed.
+func (stream *GraphStream) isLive() bool {
+	if stream.done != nil && stream.done.Err() != nil {
+		return false
+	}
+	stream.errMu.Lock()
+	defer stream.errMu.Unlock()
+	return stream.err == nil
+}
+
+// PushGraph sends a copy of X, returning false if the stream has been cancelled.
+func (stream *GraphStream) PushGraph(X State) bool {
+	return stream.Send(X.MakeCopy())
 }
 
 func (stream *GraphStream) PullGraph() State {
@@ -51,53 +195,409 @@ func (stream *GraphStream) PullAll() int {
 	return count
 }
 
+// All returns an iterator over the States in this stream.
+//
+// Each State is reclaimed once yield returns, so it must be copied (via MakeCopy) if it is retained.
+// Breaking out of the loop cancels this stream (and everything upstream of it), reclaiming any States in flight.
+func (stream *GraphStream) All() iter.Seq[State] {
+	return func(yield func(State) bool) {
+		for X := range stream.Outlet {
+			more := yield(X)
+			X.Reclaim()
+			if !more {
+				stream.Cancel()
+				return
+			}
+		}
+	}
+}
+
+// Results is like All but pairs each State with a nil error.  If the stream fails, a final (nil, Err()) is yielded.
+func (stream *GraphStream) Results() iter.Seq2[State, error] {
+	return func(yield func(State, error) bool) {
+		for X := range stream.Outlet {
+			more := yield(X, nil)
+			X.Reclaim()
+			if !more {
+				stream.Cancel()
+				return
+			}
+		}
+		if err := stream.Err(); err != nil {
+			yield(nil, err)
+		}
+	}
+}
+
+// startStage starts a goroutine that feeds each State in this stream to the given proc, which
+// passes it on to the returned stream or reclaims it.  If proc returns false (typically after calling next.Fail()), the stage stops.
+//
+// When the stage stops early (or ctx is done), this stream is cancelled so that upstream stages stop as well.
+// onDone, if given, is called after the last State has been processed but before the returned stream is closed.
+func (stream *GraphStream) startStage(
+	ctx context.Context,
+	proc func(X State, next *GraphStream) bool,
+	onDone func(),
+) *GraphStream {
+	next := newGraphStream(ctx, 1, stream)
+
+	go func() {
+		defer next.Close()
+		if onDone != nil {
+			defer onDone()
+		}
+
+		for {
+			select {
+			case X, ok := <-stream.Outlet:
+				if !ok {
+					return
+				}
+				if !proc(X, next) {
+					stream.Cancel()
+					return
+				}
+			case <-next.Done():
+				stream.Cancel()
+				return
+			}
+		}
+	}()
+
+	return next
+}
+
+// StageOpts specifies how a stage that processes each State independently is run.
+//
+// The zero value runs the stage on a single goroutine, preserving stream order.
+type StageOpts struct {
+	Workers   int  // number of goroutines processing States; <= 1 denotes one goroutine, < 0 denotes one per CPU
+	Unordered bool // if set, States are emitted as soon as they are processed (rather than in the order received)
+}
+
+func (opts StageOpts) numWorkers() int {
+	if opts.Workers < 0 {
+		return runtime.NumCPU()
+	}
+	return max(opts.Workers, 1)
+}
+
+// stageProc processes a State received by a parallel stage, which takes ownership of X.
+// It returns the State to emit (typically X), or nil if X was dropped (and reclaimed).
+// If an error is returned, X must have been reclaimed and the stage fails.
+type stageProc func(X State) (State, error)
+
+type stageJob struct {
+	X      State
+	err    error
+	result chan State // receives the processed State (or nil)
+}
+
+// startParallelStage starts a stage that feeds each State in this stream to proc across opts.Workers goroutines.
+//
+// Ownership semantics match startStage: every State is either emitted to the returned stream or reclaimed,
+// including when the stage fails or is cancelled.
+func (stream *GraphStream) startParallelStage(ctx context.Context, opts StageOpts, proc stageProc) *GraphStream {
+	numWorkers := opts.numWorkers()
+	if numWorkers == 1 {
+		return stream.startStage(ctx, func(X State, next *GraphStream) bool {
+			Y, err := proc(X)
+			if err != nil {
+				next.Fail(err)
+				return false
+			}
+			if Y == nil {
+				return true
+			}
+			return next.Send(Y)
+		}, nil)
+	}
+
+	next := newGraphStream(ctx, numWorkers, stream)
+
+	// Called once all workers have exited
+	finish := func() {
+		if !next.isLive() {
+			stream.Cancel()
+		}
+		next.Close()
+	}
+
+	fail := func(err error) {
+		next.Fail(err)
+		next.cancel()
+	}
+
+	if opts.Unordered {
+		wg := sync.WaitGroup{}
+		for i := 0; i < numWorkers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					select {
+					case X, ok := <-stream.Outlet:
+						if !ok {
+							return
+						}
+						Y, err := proc(X)
+						if err != nil {
+							fail(err)
+							return
+						}
+						if Y == nil {
+							continue
+						}
+						if !next.isLive() {
+							Y.Reclaim() // another worker failed
+							return
+						}
+						if !next.Send(Y) {
+							return
+						}
+					case <-next.Done():
+						return
+					}
+				}
+			}()
+		}
+
+		go func() {
+			wg.Wait()
+			finish()
+		}()
+
+		return next
+	}
+
+	// Ordered: the dispatcher hands jobs to workers and queues them (in input order) for the collector
+	jobs := make(chan *stageJob, numWorkers)
+	pending := make(chan *stageJob, 2*numWorkers)
+
+	go func() {
+		defer close(jobs)
+		defer close(pending)
+		for {
+			select {
+			case X, ok := <-stream.Outlet:
+				if !ok {
+					return
+				}
+				job := &stageJob{
+					X:      X,
+					result: make(chan State, 1),
+				}
+				select {
+				case pending <- job:
+					jobs <- job
+				case <-next.Done():
+					X.Reclaim()
+					return
+				}
+			case <-next.Done():
+				return
+			}
+		}
+	}()
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				var Y State
+				if next.isLive() {
+					Y, job.err = proc(job.X)
+				} else {
+					job.X.Reclaim()
+				}
+				job.X = nil
+				job.result <- Y
+			}
+		}()
+	}
+
+	go func() {
+		for job := range pending {
+			Y := <-job.result
+			switch {
+			case job.err != nil:
+				fail(job.err)
+			case Y == nil:
+			case next.isLive():
+				next.Send(Y) // reclaims Y if cancelled
+			default:
+				Y.Reclaim() // the stage has failed or been cancelled
+			}
+		}
+		wg.Wait()
+		finish()
+	}()
+
+	return next
+}
+
 // Marshals each graph in the stream to a CSV-compatible line and writes it to the output stream.
 func (stream *GraphStream) Print(
+	ctx context.Context,
 	out io.WriteCloser,
 	opts PrintOpts) *GraphStream {
 
-	next := &GraphStream{
-		Outlet: make(chan State, 1),
+	count := 0
+	return stream.startStage(ctx, func(X State, next *GraphStream) bool {
+		count++
+
+		if len(opts.Label) > 0 {
+			fmt.Fprintf(out, "%s,", opts.Label)
+		}
+
+		out.Write([]byte(fmt.Sprintf("%06d,", count)))
+
+		err := X.WriteCSV(out, opts)
+		if err != nil {
+			next.Fail(NewGraphError(err, X))
+			X.Reclaim()
+			return false
+		}
+		out.Write([]byte{'\n'})
+		return next.Send(X)
+	}, func() {
+		out.Close()
+	})
+}
+
+func (stream *GraphStream) AddTo(ctx context.Context, target GraphAdder) *GraphStream {
+	return stream.startStage(ctx, func(X State, next *GraphStream) bool {
+		wasAdded := target.TryAddGraph(X)
+		if wasAdded {
+			return next.Send(X)
+		}
+		X.Reclaim()
+		return true
+	}, nil)
+}
+
+// Tee duplicates this stream into n streams, each receiving every State (the last live stream receives the original and the others a MakeCopy()).
+//
+// The returned streams advance together, so each must be consumed (or cancelled).  Cancelling one returned stream
+// only stops delivery to that stream; this stream is cancelled once all of them are (or once ctx is done).
+//
+// Returns ErrBadStreamParam if n < 1.
+func (stream *GraphStream) Tee(ctx context.Context, n int) ([]*GraphStream, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("%w: Tee count must be >= 1 (got %d)", ErrBadStreamParam, n)
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	branches := make([]*GraphStream, n)
+	for i := range branches {
+		branches[i] = newGraphStream(ctx, 1, stream)
+		branches[i].teed = true
+	}
+
+	// send reclaims X rather than sending it if branch has been canceled, so the remaining branches keep receiving.
+	// It reports false only if ctx is done, in which case X is also reclaimed.
+	send := func(branch *GraphStream, X State) bool {
+		select {
+		case branch.Outlet <- X:
+			return true
+		case <-branch.Done():
+			X.Reclaim()
+			return true
+		case <-ctx.Done():
+			X.Reclaim()
+			return false
+		}
 	}
 
 	go func() {
+		defer func() {
+			for _, branch := range branches {
+				branch.Close()
+			}
+		}()
 
-		count := 0
-		for X := range stream.Outlet {
-			count++
+		live := make([]*GraphStream, 0, n)
+		for {
+			var X State
+			select {
+			case Xin, ok := <-stream.Outlet:
+				if !ok {
+					return
+				}
+				X = Xin
+			case <-ctx.Done():
+				stream.Cancel()
+				return
+			}
 
-			if len(opts.Label) > 0 {
-				fmt.Fprintf(out, "%s,", opts.Label)
+			live = live[:0]
+			for _, branch := range branches {
+				if branch.done.Err() == nil {
+					live = append(live, branch)
+				}
 			}
+			if len(live) == 0 {
+				X.Reclaim()
+				stream.Cancel()
+				return
+			}
+			last := len(live) - 1
+			for _, branch := range live[:last] {
+				if !send(branch, X.MakeCopy()) {
+					X.Reclaim()
+					stream.Cancel()
+					return
+				}
+			}
+			if !send(live[last], X) {
+				stream.Cancel()
+				return
+			}
+		}
+	}()
+
+	return branches, nil
+}
 
-			out.Write([]byte(fmt.Sprintf("%06d,", count)))
+// Merge combines the given streams into one, emitting States as they arrive (so order is not preserved).
+// Cancelling the returned stream cancels all the given streams.
+func Merge(ctx context.Context, streams ...*GraphStream) *GraphStream {
+	next := newGraphStream(ctx, len(streams), streams...)
 
-			err := X.WriteCSV(out, opts)
-			if err != nil {
-				panic(err)
+	wg := sync.WaitGroup{}
+	for _, src := range streams {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if !forwardAll(src, next) {
+				src.Cancel()
 			}
-			out.Write([]byte{'\n'})
-			next.Outlet <- X
-		}
-		out.Close()
+		}()
+	}
+
+	go func() {
+		wg.Wait()
 		next.Close()
 	}()
 
 	return next
 }
 
-func (stream *GraphStream) AddTo(target GraphAdder) *GraphStream {
-	next := &GraphStream{
-		Outlet: make(chan State, 1),
-	}
+// Concat combines the given streams into one, emitting all States from each stream in turn.
+// Cancelling the returned stream cancels all the given streams.
+func Concat(ctx context.Context, streams ...*GraphStream) *GraphStream {
+	next := newGraphStream(ctx, 1, streams...)
 
 	go func() {
-		for X := range stream.Outlet {
-			wasAdded := target.TryAddGraph(X)
-			if wasAdded {
-				next.Outlet <- X
-			} else {
-				X.Reclaim()
+		for i, src := range streams {
+			if !forwardAll(src, next) {
+				for _, remain := range streams[i:] {
+					remain.Cancel()
+				}
+				break
 			}
 		}
 		next.Close()
@@ -106,22 +606,38 @@ func (stream *GraphStream) AddTo(target GraphAdder) *GraphStream {
 	return next
 }
 
-func SelectFromCatalog(cat Catalog, sel GraphSelector) *GraphStream {
-	next := &GraphStream{
-		Outlet: make(chan State, 1),
+// forwardAll sends every State from src to dst, returning false if dst was cancelled first.
+func forwardAll(src, dst *GraphStream) bool {
+	for {
+		select {
+		case X, ok := <-src.Outlet:
+			if !ok {
+				return true
+			}
+			if !dst.Send(X) {
+				return false
+			}
+		case <-dst.Done():
+			return false
+		}
 	}
+}
+
+func SelectFromCatalog(ctx context.Context, cat Catalog, sel GraphSelector) *GraphStream {
+	next := newGraphStream(ctx, 1)
 
 	onHit := make(chan State, 4)
 
 	go func() {
-		cat.Select(sel, onHit)
+		err := cat.Select(next.done, sel, onHit)
+		next.Fail(err)
 		close(onHit)
 	}()
 
 	go func() {
 		for X := range onHit {
 			if sel.SelectsGraph(X) {
-				next.Outlet <- X
+				next.Send(X)
 			} else {
 				X.Reclaim()
 			}
@@ -132,55 +648,139 @@ func SelectFromCatalog(cat Catalog, sel GraphSelector) *GraphStream {
 	return next
 }
 
-func (stream *GraphStream) SelectFromStream(sel GraphSelector) *GraphStream {
-	next := &GraphStream{
-		Outlet: make(chan State, 1),
+// SelectAll returns an iterator over the graphs in cat meeting the given selection criteria (see SelectFromCatalog and Results).
+func SelectAll(ctx context.Context, cat Catalog, sel GraphSelector) iter.Seq2[State, error] {
+	return SelectFromCatalog(ctx, cat, sel).Results()
+}
+
+// SelectQuery is like SelectFromCatalog but selects the graphs meeting the given query (see ParseQuery).
+//
+// If the query fails to parse, the returned stream fails immediately with ErrBadQuery.
+func SelectQuery(ctx context.Context, cat Catalog, query string) *GraphStream {
+	sel, err := ParseQuery(query)
+	if err != nil {
+		next := newGraphStream(ctx, 0)
+		next.Fail(err)
+		next.Close()
+		return next
 	}
+	return SelectFromCatalog(ctx, cat, sel)
+}
 
-	go func() {
-		var matchTraces Traces
-		if sel.Traces != nil {
-			matchTraces = sel.Traces.Traces(0)
+// SelectQuery is like SelectFromStream but selects the graphs meeting the given query (see ParseQuery).
+//
+// If the query fails to parse, the returned stream fails immediately with ErrBadQuery (and this stream is cancelled).
+// Since a query can't name a PrimeTester, "prime" fails with ErrNoPrimeTester (set GraphSelector.Primes and use SelectFromStream instead).
+func (stream *GraphStream) SelectQuery(ctx context.Context, query string, opts StageOpts) *GraphStream {
+	sel, err := ParseQuery(query)
+	if err != nil {
+		return stream.closedStage(ctx, err)
+	}
+	return stream.SelectFromStream(ctx, sel, opts)
+}
+
+// SelectFromStream emits only the graphs in this stream that meet the given selection criteria.
+//
+// If sel can't be applied to a stream (see GraphSelector), the returned stream fails immediately (and this stream is cancelled).
+// If sel.UniqueTraces is set, the first graph (in stream order) having each Traces is selected, so opts.Unordered is ignored.
+func (stream *GraphStream) SelectFromStream(ctx context.Context, sel GraphSelector, opts StageOpts) *GraphStream {
+	var selErr error
+	switch {
+	case sel.Factor:
+		selErr = ErrFactorOnStream
+	case sel.SelectPrimes && sel.Primes == nil:
+		selErr = ErrNoPrimeTester
+	}
+	if selErr != nil {
+		return stream.closedStage(ctx, selErr)
+	}
+
+	var matchTraces Traces
+	if sel.Traces != nil {
+		matchTraces = sel.Traces.Traces(0)
+	}
+	matchLen := len(matchTraces)
+
+	if sel.UniqueTraces {
+		opts.Unordered = false
+	}
+
+	next := stream.startParallelStage(ctx, opts, func(X State) (State, error) {
+		keep := sel.SelectsGraph(X)
+		if keep && matchLen > 0 {
+			keep = matchTraces.IsEqual(X.Traces(matchLen))
 		}
-		matchLen := len(matchTraces)
-		for X := range stream.Outlet {
-			keep := false
-			if sel.SelectsGraph(X) {
-				keep = true
-				if matchLen > 0 {
-					TX := X.Traces(matchLen)
-					keep = matchTraces.IsEqual(TX)
-				}
-			}
-			if keep {
-				next.Outlet <- X
-			} else {
-				X.Reclaim()
+		if keep {
+			keep = sel.SelectsGraphTraces(X)
+		}
+		if keep && (sel.SelectBosons || sel.SelectPrimes) {
+			TX := X.Traces(X.VertexCount())
+			if sel.SelectBosons && !TX.IsBoson() {
+				keep = false
+			} else if sel.SelectPrimes && !sel.Primes.IsPrime(TX) {
+				keep = false
 			}
 		}
-		next.Close()
-	}()
 
-	return next
+		if keep {
+			return X, nil
+		}
+		X.Reclaim()
+		return nil, nil
+	})
+	if !sel.UniqueTraces {
+		return next
+	}
+
+	// UniqueTraces gating is done by a single (in order) stage so that the same graph is always selected
+	seen := make(map[string]struct{})
+	return next.startParallelStage(ctx, StageOpts{}, func(X State) (State, error) {
+		var keyBuf [256]byte
+		key := KeyByTraces(0)(X, keyBuf[:0])
+		if _, dupe := seen[string(key)]; dupe {
+			X.Reclaim()
+			return nil, nil
+		}
+		seen[string(key)] = struct{}{}
+		return X, nil
+	})
 }
 
-func (stream *GraphStream) Canonize(normalize bool) *GraphStream {
-	next := &GraphStream{
-		Outlet: make(chan State, 1),
-	}
+func (stream *GraphStream) Canonize(ctx context.Context, normalize bool, opts StageOpts) *GraphStream {
+	return stream.startParallelStage(ctx, opts, func(X State) (State, error) {
+		err := X.Canonize(normalize)
+		if err != nil {
+			err = NewGraphError(err, X)
+			X.Reclaim()
+			return nil, err
+		}
+		return X, nil
+	})
+}
 
-	go func() {
-		for X := range stream.Outlet {
-			err := X.Canonize(normalize)
-			if err != nil {
-				panic(err)
-			}
-			next.Outlet <- X
+// EdgeGroupDual replaces each graph with its edge group dual (see EdgeGroupDualProvider), so the dual's invariants can be
+// compared with those of the original.  The stage fails if a graph does not support forming its dual.
+func (stream *GraphStream) EdgeGroupDual(ctx context.Context, opts StageOpts) *GraphStream {
+	return stream.startParallelStage(ctx, opts, func(X State) (State, error) {
+		defer X.Reclaim()
+		dualer, ok := X.(EdgeGroupDualProvider)
+		if !ok {
+			return nil, NewGraphError(ErrNotSupported, X)
 		}
-		next.Close()
-	}()
+		dual, err := dualer.EdgeGroupDual()
+		if err != nil {
+			return nil, NewGraphError(err, X)
+		}
+		return dual, nil
+	})
+}
 
-	return next
+// PrefetchTraces computes (and so caches) the first numTraces Traces of each graph so that later stages don't have to.
+func (stream *GraphStream) PrefetchTraces(ctx context.Context, numTraces int, opts StageOpts) *GraphStream {
+	return stream.startParallelStage(ctx, opts, func(X State) (State, error) {
+		X.Traces(numTraces)
+		return X, nil
+	})
 }
 
 /*
@@ -256,34 +856,18 @@ func (ctx *canonizeCtx) goCanonize(X *Graph) error {
 }
 */
 
-func (stream *GraphStream) PermuteVtxSigns() *GraphStream {
-	next := &GraphStream{
-		Outlet: make(chan State, 1),
-	}
-
-	go func() {
-		for Xsrc := range stream.Outlet {
-			Xsrc.PermuteVtxSigns(next)
-			Xsrc.Reclaim()
-		}
-		next.Close()
-	}()
-
-	return next
+func (stream *GraphStream) PermuteVtxSigns(ctx context.Context) *GraphStream {
+	return stream.startStage(ctx, func(Xsrc State, next *GraphStream) bool {
+		Xsrc.PermuteVtxSigns(next)
+		Xsrc.Reclaim()
+		return next.isLive()
+	}, nil)
 }
 
-func (stream *GraphStream) PermuteEdgeSigns() *GraphStream {
-	next := &GraphStream{
-		Outlet: make(chan State, 1),
-	}
-
-	go func() {
-		for Xsrc := range stream.Outlet {
-			Xsrc.PermuteEdgeSigns(next)
-			Xsrc.Reclaim()
-		}
-		next.Close()
-	}()
-
-	return next
+func (stream *GraphStream) PermuteEdgeSigns(ctx context.Context) *GraphStream {
+	return stream.startStage(ctx, func(Xsrc State, next *GraphStream) bool {
+		Xsrc.PermuteEdgeSigns(next)
+		Xsrc.Reclaim()
+		return next.isLive()
+	}, nil)
 }
@@ -0,0 +1,310 @@
+package go2x3
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/alecthomas/participle/v2"
+	"github.com/alecthomas/participle/v2/lexer"
+)
+
+// ParseQuery compiles the given graph query into a GraphSelector, such as:
+//
+//	v in 4..8 && prime && neg_edges<=2 && T1==5 && T2>=13
+//
+// Each term is joined by "&&" (or "and") and is one of:
+//
+//	parts, verts, neg_loops, pos_loops, neg_edges, pos_edges  (or p, v)   compared using ==, <, <=, >, >= or "in lo..hi"
+//	prime, boson, unique, factor                                          sets SelectPrimes, SelectBosons, UniqueTraces or Factor
+//	traces == "<graph>"                                                   sets Traces to a graph in any encoding (see DecodeGraph)
+//	T1, T2, ... or odd, even, all                                         compared using ==, !=, <, <=, >, >= or "in lo..hi"
+//	T1 % m, T2 % m, ... or odd % m, even % m, all % m                     compared using == or !=
+//	P1, P2, ... or p_odd, p_even, p_all                                   primitive Traces terms, compared as above
+//
+// Names are case-insensitive (e.g. "T1" or "t1", "Prime" or "prime").  Bounds on GraphInfo fields must be within 0..255.
+//
+// Terms on Traces compile into GraphSelector.Predicates (see TracesCond and TracesMod), where "odd", "even" and "all"
+// compare each of those terms (e.g. "odd == 0") and "P" terms compare the primitive Traces (see Traces.Primitive).  A Traces term or "boson" may be negated with "!" or "not" (see TracesNot).
+func ParseQuery(query string) (GraphSelector, error) {
+	sel := DefaultGraphSelector
+
+	expr, err := parseQueryExpr.ParseString("", query)
+	if err != nil {
+		return sel, fmt.Errorf("%w: %v", ErrBadQuery, err)
+	}
+
+	for _, term := range expr.Terms {
+		if err := term.applyTo(&sel); err != nil {
+			return sel, fmt.Errorf("%w: %v: %v", ErrBadQuery, term.Pos, err)
+		}
+	}
+	return sel, nil
+}
+
+// ParseTracesPredicates compiles the given query into TracesPredicates, where each term must be a Traces term or "!boson" (see ParseQuery).
+func ParseTracesPredicates(query string) ([]TracesPredicate, error) {
+	expr, err := parseQueryExpr.ParseString("", query)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBadQuery, err)
+	}
+
+	sel := DefaultGraphSelector
+	for _, term := range expr.Terms {
+		numPreds := len(sel.Predicates)
+		if err := term.applyTo(&sel); err != nil {
+			return nil, fmt.Errorf("%w: %v: %v", ErrBadQuery, term.Pos, err)
+		}
+		if len(sel.Predicates) == numPreds {
+			return nil, fmt.Errorf("%w: %v: %q is not a Traces term", ErrBadQuery, term.Pos, term.Name)
+		}
+	}
+	return sel.Predicates, nil
+}
+
+// queryExpr is the participle grammar of a graph query.
+type queryExpr struct {
+	Terms []*queryTerm `parser:"@@ ( ( '&&' | 'and' ) @@ )*"`
+}
+
+type queryTerm struct {
+	Pos   lexer.Position
+	Not   bool        `parser:"@( '!' | 'not' )?"`
+	Name  string      `parser:"@Ident"`
+	Mod   *int64      `parser:"( '%' @Int )?"`
+	Range *queryRange `parser:"( 'in' @@"`
+	Op    string      `parser:"| @( '==' | '!=' | '<=' | '>=' | '<' | '>' )"`
+	Value int64       `parser:"  ( @Int"`
+	Graph *string     `parser:"  | @String ) )?"`
+}
+
+type queryRange struct {
+	Lo int64 `parser:"@Int"`
+	Hi int64 `parser:"'..' @Int"`
+}
+
+var queryLexer = lexer.MustSimple([]lexer.SimpleRule{
+	{Name: "String", Pattern: `"(\\.|[^"])*"`},
+	{Name: "Range", Pattern: `\.\.`},
+	{Name: "Op", Pattern: `&&|==|!=|<=|>=|<|>|%|!`},
+	{Name: "Int", Pattern: `-?\d+`},
+	{Name: "Ident", Pattern: `[A-Za-z_][A-Za-z_0-9]*`},
+	{Name: "whitespace", Pattern: `\s+`},
+})
+
+var parseQueryExpr = participle.MustBuild[queryExpr](participle.Lexer(queryLexer), participle.Unquote("String"), participle.CaseInsensitive("Ident"))
+
+var (
+	graphDecoderMu sync.RWMutex
+	graphDecoder   func(enc []byte) (State, error)
+)
+
+// RegisterGraphDecoder sets the decoder DecodeGraph uses, which is registered by the package implementing graph
+// encodings (i.e. lib2x3/graph) since go2x3 can't depend on it.
+func RegisterGraphDecoder(decode func(enc []byte) (State, error)) {
+	graphDecoderMu.Lock()
+	graphDecoder = decode
+	graphDecoderMu.Unlock()
+}
+
+// DecodeGraph returns a new State from the given graph encoding, which may be in any registered form (e.g. "1-2-3" or
+// a hex op string), returning ErrNotSupported if no graph decoder is registered (see RegisterGraphDecoder).
+func DecodeGraph(enc []byte) (State, error) {
+	graphDecoderMu.RLock()
+	decode := graphDecoder
+	graphDecoderMu.RUnlock()
+	if decode == nil {
+		return nil, ErrNotSupported
+	}
+	return decode(enc)
+}
+
+// queryFields maps each query field name to its GraphInfo field.
+var queryFields = map[string]func(info *GraphInfo) *byte{
+	"p":         func(info *GraphInfo) *byte { return &info.NumParticles },
+	"parts":     func(info *GraphInfo) *byte { return &info.NumParticles },
+	"v":         func(info *GraphInfo) *byte { return &info.NumVertex },
+	"verts":     func(info *GraphInfo) *byte { return &info.NumVertex },
+	"neg_loops": func(info *GraphInfo) *byte { return &info.NegLoops },
+	"pos_loops": func(info *GraphInfo) *byte { return &info.PosLoops },
+	"neg_edges": func(info *GraphInfo) *byte { return &info.NegEdges },
+	"pos_edges": func(info *GraphInfo) *byte { return &info.PosEdges },
+}
+
+func (term *queryTerm) applyTo(sel *GraphSelector) error {
+	name := term.Name
+	key := strings.ToLower(name) // names are case-insensitive
+	hasCompare := term.Range != nil || term.Op != ""
+
+	// flags
+	var flag *bool
+	switch key {
+	case "prime", "primes":
+		flag = &sel.SelectPrimes
+	case "boson", "bosons":
+		flag = &sel.SelectBosons
+	case "unique":
+		flag = &sel.UniqueTraces
+	case "factor":
+		flag = &sel.Factor
+	}
+	if flag != nil {
+		if hasCompare || term.Mod != nil {
+			return fmt.Errorf("%q is a flag and takes no comparison", name)
+		}
+		if term.Not {
+			if flag != &sel.SelectBosons {
+				return fmt.Errorf("%q can't be negated", name)
+			}
+			sel.Predicates = append(sel.Predicates, TracesNot{TracesCond{Terms: OddTerms, Op: OpEQ}})
+			return nil
+		}
+		*flag = true
+		return nil
+	}
+	if !hasCompare {
+		return fmt.Errorf("%q requires a comparison", name)
+	}
+
+	// A graph to match Traces with
+	if key == "traces" || term.Graph != nil {
+		if term.Graph == nil || term.Op != "==" || term.Not || term.Mod != nil {
+			return fmt.Errorf("%q only supports == \"<graph>\"", name)
+		}
+		if key != "traces" {
+			return fmt.Errorf("%q does not take a graph", name)
+		}
+		X, err := DecodeGraph([]byte(*term.Graph))
+		if err != nil {
+			return fmt.Errorf("bad graph %q: %w", *term.Graph, err)
+		}
+		sel.Traces = X
+		return nil
+	}
+
+	// Traces terms
+	if index, terms, primitive, ok := parseTermName(key); ok {
+		preds, err := term.tracesPredicates(index, terms, primitive)
+		if err != nil {
+			return err
+		}
+		if term.Not {
+			preds = []TracesPredicate{TracesNot(preds)}
+		}
+		sel.Predicates = append(sel.Predicates, preds...)
+		return nil
+	}
+
+	// GraphInfo bounds
+	field := queryFields[key]
+	if field == nil {
+		return fmt.Errorf("unknown field %q", name)
+	}
+	if term.Not || term.Mod != nil {
+		return fmt.Errorf("%q only supports bounds", name)
+	}
+	lo, hi := int64(0), int64(255)
+	if term.Range != nil {
+		lo, hi = term.Range.Lo, term.Range.Hi
+	} else {
+		op, err := queryOp(term.Op)
+		if err != nil {
+			return err
+		}
+		switch op {
+		case OpEQ:
+			lo, hi = term.Value, term.Value
+		case OpLT:
+			hi = term.Value - 1
+		case OpLE:
+			hi = term.Value
+		case OpGT:
+			lo = term.Value + 1
+		case OpGE:
+			lo = term.Value
+		default:
+			return fmt.Errorf("%q does not support %v", name, op)
+		}
+	}
+
+	if lo < 0 || lo > 255 || hi < 0 || hi > 255 {
+		return fmt.Errorf("%q bound is out of range (0..255)", name)
+	}
+
+	minVal, maxVal := field(&sel.Min), field(&sel.Max)
+	if lo > int64(*minVal) {
+		*minVal = byte(lo)
+	}
+	if hi < int64(*maxVal) {
+		*maxVal = byte(hi)
+	}
+	if lo > hi || *minVal > *maxVal {
+		return fmt.Errorf("%q has an empty range", name)
+	}
+	return nil
+}
+
+// tracesPredicates compiles this term into the TracesPredicates it denotes.
+func (term *queryTerm) tracesPredicates(index int, terms TermSet, primitive bool) ([]TracesPredicate, error) {
+	var op CompareOp
+	if term.Range == nil {
+		var err error
+		if op, err = queryOp(term.Op); err != nil {
+			return nil, err
+		}
+	}
+	if term.Mod != nil {
+		if *term.Mod <= 0 {
+			return nil, fmt.Errorf("%q has a bad modulus", term.Name)
+		}
+		mod := TracesMod{Index: index, Terms: terms, Primitive: primitive, Modulus: *term.Mod, Remainder: term.Value}
+		switch {
+		case term.Range != nil:
+		case op == OpEQ:
+			return []TracesPredicate{mod}, nil
+		case op == OpNE:
+			return []TracesPredicate{TracesNot{mod}}, nil
+		}
+		return nil, fmt.Errorf("%q %% %d only supports == and !=", term.Name, *term.Mod)
+	}
+
+	if term.Range != nil {
+		return []TracesPredicate{
+			TracesCond{Index: index, Terms: terms, Primitive: primitive, Op: OpGE, Value: term.Range.Lo},
+			TracesCond{Index: index, Terms: terms, Primitive: primitive, Op: OpLE, Value: term.Range.Hi},
+		}, nil
+	}
+	return []TracesPredicate{
+		TracesCond{Index: index, Terms: terms, Primitive: primitive, Op: op, Value: term.Value},
+	}, nil
+}
+
+// parseTermName parses "t1", "t2", ... or "odd", "even", "all", or their primitive forms "p1", "p2", ... or "p_odd", "p_even", "p_all",
+// where name has been lowercased.
+func parseTermName(name string) (index int, terms TermSet, primitive bool, ok bool) {
+	if setName, isPrim := strings.CutPrefix(name, "p_"); isPrim {
+		name, primitive = setName, true
+	}
+	for i, termStr := range termSetStrs {
+		if name == termStr {
+			return 0, TermSet(i), primitive, true
+		}
+	}
+	if len(name) > 1 && (name[0] == 't' || name[0] == 'p') && !primitive {
+		if index, err := strconv.Atoi(name[1:]); err == nil && index > 0 {
+			return index, AllTerms, name[0] == 'p', true
+		}
+	}
+	return 0, AllTerms, false, false
+}
+
+// queryOp returns the CompareOp denoted by the given operator.
+func queryOp(op string) (CompareOp, error) {
+	for i, opStr := range compareOpStrs {
+		if opStr == op {
+			return CompareOp(i), nil
+		}
+	}
+	return OpEQ, fmt.Errorf("unknown operator %q", op)
+}
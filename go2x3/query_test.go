@@ -0,0 +1,137 @@
+package go2x3
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	sel, err := ParseQuery("v in 4..8 && prime && neg_edges<=2 && T1==5 && T2>=13")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sel.Min.NumVertex != 4 || sel.Max.NumVertex != 8 {
+		t.Fatalf("verts: got %d..%d", sel.Min.NumVertex, sel.Max.NumVertex)
+	}
+	if !sel.SelectPrimes || sel.SelectBosons || sel.UniqueTraces {
+		t.Fatal("flags not set as expected")
+	}
+	if sel.Max.NegEdges != 2 || sel.Min.NegEdges != 0 {
+		t.Fatalf("neg_edges: got %d..%d", sel.Min.NegEdges, sel.Max.NegEdges)
+	}
+	if sel.Max.PosEdges != DefaultGraphSelector.Max.PosEdges {
+		t.Fatal("pos_edges should be unchanged")
+	}
+	checkPredicates(t, sel.Predicates, "T1==5", "T2>=13")
+	if sel.PredicateTraces(1) != 2 || sel.PredicateTraces(4) != 4 {
+		t.Fatal("PredicateTraces failed")
+	}
+
+	sel, err = ParseQuery("p>1 and v<6 and unique and boson and T3 in -4..4 and T4!=0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sel.Min.NumParticles != 2 || sel.Max.NumVertex != 5 || !sel.UniqueTraces || !sel.SelectBosons {
+		t.Fatal("second query compiled incorrectly")
+	}
+	checkPredicates(t, sel.Predicates, "T3>=-4", "T3<=4", "T4!=0")
+
+	sel, err = ParseQuery("V IN 4..8 AND Prime AND t1 == 5 AND Not P2 > 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sel.Min.NumVertex != 4 || sel.Max.NumVertex != 8 || !sel.SelectPrimes {
+		t.Fatal("mixed case query compiled incorrectly")
+	}
+	checkPredicates(t, sel.Predicates, "T1==5", "!P2>1")
+
+	preds, err := ParseTracesPredicates("T2 % 9 == 0 && odd == 0 && !boson && not T1 in 0..4 && all % 2 != 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkPredicates(t, preds, "T2%9==0", "odd==0", "!odd==0", "!(T1>=0 && T1<=4)", "!all%2==1")
+
+	preds, err = ParseTracesPredicates("P4 > 0 && p_odd % 3 == 0 && !P2 in 1..2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkPredicates(t, preds, "P4>0", "p_odd%3==0", "!(P2>=1 && P2<=2)")
+
+	for _, bad := range []string{
+		"",
+		"v",
+		"prime == 1",
+		"!prime",
+		"wings > 2",
+		"v != 3",
+		"v % 2 == 0",
+		"v in 6..4",
+		"T0 == 1",
+		"T2 % 0 == 0",
+		"T2 % 3 < 1",
+		"P0 > 1",
+		"p_T2 > 1",
+		"v > 3 &&",
+		"v > 300",
+		"v in -1..3",
+		"neg_edges < 0",
+		"traces == 3",
+		"traces != \"1-2\"",
+		"v == \"1-2\"",
+		"traces == \"1-2\"", // no graph decoder is registered in this package
+	} {
+		if _, err := ParseQuery(bad); !errors.Is(err, ErrBadQuery) {
+			t.Fatalf("%q: expected ErrBadQuery, got %v", bad, err)
+		}
+	}
+	if _, err := ParseTracesPredicates("T1 == 0 && v > 2"); !errors.Is(err, ErrBadQuery) {
+		t.Fatal("expected ErrBadQuery for a non-Traces term")
+	}
+	if _, err := queryOp("=<"); err == nil {
+		t.Fatal("expected an error for an unknown operator")
+	}
+}
+
+func TestTracesPredicates(t *testing.T) {
+	TX := Traces{0, 18, -6, 40}
+
+	tests := []struct {
+		pred TracesPredicate
+		want bool
+	}{
+		{TracesCond{Index: 1, Op: OpEQ, Value: 0}, true},
+		{TracesCond{Index: 3, Op: OpLT, Value: 0}, true},
+		{TracesCond{Index: 5, Op: OpLT, Value: 0}, false},
+		{TracesCond{Terms: OddTerms, Op: OpLE, Value: 0}, true},
+		{TracesCond{Terms: OddTerms, Op: OpEQ, Value: 0}, false},
+		{TracesCond{Terms: EvenTerms, Op: OpGT, Value: 0}, true},
+		{TracesCond{Terms: AllTerms, Op: OpGE, Value: 0}, false},
+		{TracesMod{Index: 2, Modulus: 9}, true},
+		{TracesMod{Index: 3, Modulus: 4, Remainder: 2}, true},
+		{TracesMod{Terms: EvenTerms, Modulus: 2}, true},
+		{TracesMod{Terms: AllTerms, Modulus: 3}, false},
+		{TracesNot{TracesMod{Index: 2, Modulus: 9}}, false},
+		{TracesNot{TracesCond{Terms: OddTerms, Op: OpEQ}}, true},
+		{TracesCond{Index: 4, Primitive: true, Op: OpEQ, Value: 22}, true},
+		{TracesCond{Index: 4, Primitive: true, Op: OpEQ, Value: 40}, false},
+		{TracesMod{Terms: EvenTerms, Primitive: true, Modulus: 4, Remainder: 2}, true},
+	}
+	for _, test := range tests {
+		if got := test.pred.SelectsTraces(TX); got != test.want {
+			t.Errorf("%v: got %v, want %v", test.pred, got, test.want)
+		}
+	}
+}
+
+func checkPredicates(t *testing.T, preds []TracesPredicate, want ...string) {
+	t.Helper()
+	if len(preds) != len(want) {
+		t.Fatalf("got predicates %v, want %v", preds, want)
+	}
+	for i := range want {
+		if got := preds[i].String(); got != want[i] {
+			t.Fatalf("predicate %d: got %q, want %q", i, got, want[i])
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package go2x3
 
-import "sync"
+import (
+	"fmt"
+	"sync"
+)
 
 func (factors *FactorSet) Insert(toAdd TracesID) {
 	insertAt := len(*factors)
@@ -158,6 +161,18 @@ var DefaultGraphSelector = GraphSelector{
 	},
 }
 
+// WantFlags returns the catalog Traces entry flags (Flag_IsPrime, Flag_IsBoson) that a selected graph must have.
+func (sel *GraphSelector) WantFlags() byte {
+	flags := byte(0)
+	if sel.SelectPrimes {
+		flags |= Flag_IsPrime
+	}
+	if sel.SelectBosons {
+		flags |= Flag_IsBoson
+	}
+	return flags
+}
+
 // AllowGraph is a convenience function used to see if a Graph is selected according to a GraphSelector.
 func (sel *GraphSelector) SelectsGraph(X State) bool {
 	info := X.GraphInfo()
@@ -169,3 +184,40 @@ func (sel *GraphSelector) SelectsGraph(X State) bool {
 	}
 	return true
 }
+
+// GraphError is an error that occurred while processing a particular graph.
+type GraphError struct {
+	Err   error  // what went wrong
+	Graph string // graph expression of the offending graph (or "" if not available)
+}
+
+// NewGraphError wraps err with the graph expression of X.
+func NewGraphError(err error, X State) *GraphError {
+	return &GraphError{
+		Err:   err,
+		Graph: GraphExpr(X),
+	}
+}
+
+func (err *GraphError) Error() string {
+	if err.Graph == "" {
+		return err.Err.Error()
+	}
+	return fmt.Sprintf("%v (graph %q)", err.Err, err.Graph)
+}
+
+func (err *GraphError) Unwrap() error {
+	return err.Err
+}
+
+// GraphExpr returns the graph expression of X if X implements GraphExprProvider, otherwise "".
+func GraphExpr(X State) string {
+	if X == nil {
+		return ""
+	}
+	if provider, ok := X.(GraphExprProvider); ok {
+		var buf [128]byte
+		return string(provider.AppendGraphExpr(buf[:0]))
+	}
+	return ""
+}
@@ -0,0 +1,141 @@
+package go2x3
+
+import (
+	"cmp"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Traces arithmetic is element-wise over the shorter of the two operands (as with IsEqual and Subtract) and is in int64,
+// so for Traces that may overflow, see ExactTracesProvider.
+
+// VertexCount returns len(TX), allowing a Traces to be used as a TracesProvider (e.g. GraphSelector.Traces).
+func (TX Traces) VertexCount() int {
+	return len(TX)
+}
+
+// Traces returns the first numTraces of TX (0 denotes all of TX), allowing a Traces to be used as a TracesProvider.
+//
+// Unlike a graph, a Traces can't compute more terms than it has, so if numTraces > len(TX), all of TX is returned
+// (fewer terms than requested).  Callers needing numTraces terms should check the length (see ErrInsufficientTraces and ExtendTraces).
+func (TX Traces) Traces(numTraces int) Traces {
+	if numTraces <= 0 || numTraces > len(TX) {
+		return TX
+	}
+	return TX[:numTraces]
+}
+
+// Add returns TX + other, which is the Traces of the union of the two graphs.
+func (TX Traces) Add(other Traces) Traces {
+	sum := make(Traces, mini(len(TX), len(other)))
+	for i := range sum {
+		sum[i] = TX[i] + other[i]
+	}
+	return sum
+}
+
+// Sub returns TX - other (see Subtract).
+func (TX Traces) Sub(other Traces) Traces {
+	var diff Traces
+	TX.Subtract(other, &diff)
+	return diff
+}
+
+// Scale returns k * TX, which for k > 0 is the Traces of k copies of a graph.
+func (TX Traces) Scale(k int64) Traces {
+	scaled := make(Traces, len(TX))
+	for i, Ti := range TX {
+		scaled[i] = k * Ti
+	}
+	return scaled
+}
+
+// NegateOdd returns TX with its odd terms (T1, T3, ...) negated, which is the Traces of a graph's antiparticle
+// (the graph with all its edge and loop signs inverted).
+func (TX Traces) NegateOdd() Traces {
+	anti := make(Traces, len(TX))
+	for i, Ti := range TX {
+		if i&1 == 0 {
+			Ti = -Ti
+		}
+		anti[i] = Ti
+	}
+	return anti
+}
+
+// Dot returns the dot product of TX and other.
+func (TX Traces) Dot(other Traces) int64 {
+	dot := int64(0)
+	for i, N := 0, mini(len(TX), len(other)); i < N; i++ {
+		dot += TX[i] * other[i]
+	}
+	return dot
+}
+
+// Compare orders TX and other lexicographically, returning -1, 0, or +1.
+// If one is a prefix of the other, the shorter sorts first.
+func (TX Traces) Compare(other Traces) int {
+	for i, N := 0, mini(len(TX), len(other)); i < N; i++ {
+		if c := cmp.Compare(TX[i], other[i]); c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(TX), len(other))
+}
+
+// CompareMagnitude orders TX and other lexicographically by the absolute value of each term, returning -1, 0, or +1.
+// So a particle and its antiparticle (see NegateOdd) have the same magnitude.
+func (TX Traces) CompareMagnitude(other Traces) int {
+	for i, N := 0, mini(len(TX), len(other)); i < N; i++ {
+		if c := cmp.Compare(absInt64(TX[i]), absInt64(other[i])); c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(TX), len(other))
+}
+
+// String returns TX in text form, e.g. "5,13,35" (see ParseTraces).
+func (TX Traces) String() string {
+	return string(TX.AppendText(nil))
+}
+
+// AppendText appends the text form of TX to the given buffer (see String).
+func (TX Traces) AppendText(out []byte) []byte {
+	for i, Ti := range TX {
+		if i > 0 {
+			out = append(out, ',')
+		}
+		out = strconv.AppendInt(out, Ti, 10)
+	}
+	return out
+}
+
+// ParseTraces parses the text form of a Traces, e.g. "5,13,35".
+// Enclosing brackets or parentheses and spaces are also accepted, so "(5, 13, 35)" and "[5 13 35]" parse the same.
+func ParseTraces(str string) (Traces, error) {
+	str = strings.TrimSpace(str)
+	if len(str) >= 2 && (str[0] == '(' && str[len(str)-1] == ')' || str[0] == '[' && str[len(str)-1] == ']') {
+		str = str[1 : len(str)-1]
+	}
+
+	terms := strings.FieldsFunc(str, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+	TX := make(Traces, len(terms))
+	for i, term := range terms {
+		Ti, err := strconv.ParseInt(term, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("bad traces term %q: %w", term, err)
+		}
+		TX[i] = Ti
+	}
+	return TX, nil
+}
+
+func absInt64(x int64) int64 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
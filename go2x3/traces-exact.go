@@ -0,0 +1,137 @@
+package go2x3
+
+import (
+	"math"
+	"math/big"
+	"math/bits"
+	"strings"
+)
+
+// ExactTracesProvider is optionally implemented by a TracesProvider able to detect Traces overflow and compute exact Traces.
+//
+// Traces grow roughly as 3^i, so for larger graphs int64 Traces overflow past about C36 (and sooner for graphs with multi-edge counts).
+type ExactTracesProvider interface {
+	TracesProvider
+
+	// CheckedTraces is Traces but returns ErrTracesOverflow if any of the requested Traces overflow an int64.
+	CheckedTraces(numTraces int) (Traces, error)
+
+	// BigTraces returns the requested number of Traces (0 denotes the vertex count) as arbitrary-precision integers.
+	BigTraces(numTraces int) BigTraces
+}
+
+// CheckedTraces returns X.CheckedTraces(numTraces) if X is an ExactTracesProvider, otherwise X.Traces(numTraces).
+func CheckedTraces(X TracesProvider, numTraces int) (Traces, error) {
+	if exact, ok := X.(ExactTracesProvider); ok {
+		return exact.CheckedTraces(numTraces)
+	}
+	return X.Traces(numTraces), nil
+}
+
+// NumExactTraces returns how many leading Traces of a graph having Nv vertices are certain to fit in an int64, where
+// maxRowNorm is the largest sum of the magnitudes of the edge weights into any one vertex.  Since each entry of A^i is
+// bounded by maxRowNorm^i, Ti is bounded by Nv*maxRowNorm^i.
+//
+// Since int64 arithmetic wraps (i.e. is exact modulo 2^64), Traces past this bound are still exact unless they
+// actually exceed the range of an int64, which BigTraces can confirm.
+func NumExactTraces(Nv int, maxRowNorm uint64) int {
+	if maxRowNorm <= 1 {
+		return math.MaxInt
+	}
+	bound := uint64(Nv)
+	for n := 0; ; n++ {
+		hi, lo := bits.Mul64(bound, maxRowNorm)
+		if hi != 0 || lo > math.MaxInt64 {
+			return n
+		}
+		bound = lo
+	}
+}
+
+// BigTraces is an arbitrary-precision Traces, used when Traces exceed the range of an int64.
+type BigTraces []*big.Int
+
+// Traces returns TX as a Traces, or ErrTracesOverflow if a term exceeds the range of an int64.
+func (TX BigTraces) Traces() (Traces, error) {
+	out := make(Traces, len(TX))
+	for i, Ti := range TX {
+		if !Ti.IsInt64() {
+			return nil, ErrTracesOverflow
+		}
+		out[i] = Ti.Int64()
+	}
+	return out, nil
+}
+
+func (TX BigTraces) String() string {
+	var str strings.Builder
+	for i, Ti := range TX {
+		if i > 0 {
+			str.WriteByte(',')
+		}
+		str.WriteString(Ti.String())
+	}
+	return str.String()
+}
+
+// BigTracesOf returns the first numTraces Traces of the Nv x Nv matrix A (stored row-major), where Ti = trace(A^i).
+func BigTracesOf(A []int64, Nv int, numTraces int) BigTraces {
+	if numTraces <= 0 {
+		numTraces = Nv
+	}
+
+	NvNv := Nv * Nv
+	Abig := make([]big.Int, NvNv)
+	for i, Aij := range A[:NvNv] {
+		Abig[i].SetInt64(Aij)
+	}
+
+	// Ci holds A^i, starting with the identity
+	Ci0 := make([]big.Int, NvNv)
+	Ci1 := make([]big.Int, NvNv)
+	for i := 0; i < Nv; i++ {
+		Ci0[i*Nv+i].SetInt64(1)
+	}
+
+	var term big.Int
+	TX := make(BigTraces, numTraces)
+	for ti := range TX {
+		Ti := new(big.Int)
+		for i := 0; i < Nv; i++ {
+			for j := 0; j < Nv; j++ {
+				Cij := &Ci1[i*Nv+j]
+				Cij.SetInt64(0)
+				for k := 0; k < Nv; k++ {
+					if A[i*Nv+k] != 0 {
+						Cij.Add(Cij, term.Mul(&Abig[i*Nv+k], &Ci0[k*Nv+j]))
+					}
+				}
+			}
+			Ti.Add(Ti, &Ci1[i*Nv+i])
+		}
+		TX[ti] = Ti
+		Ci0, Ci1 = Ci1, Ci0
+	}
+	return TX
+}
+
+// AddChecked returns a + b and false if the sum overflows an int64.
+func AddChecked(a, b int64) (int64, bool) {
+	sum := a + b
+	if (b > 0 && sum < a) || (b < 0 && sum > a) {
+		return 0, false
+	}
+	return sum, true
+}
+
+// MulAddChecked returns acc + a*b and false if the result (or the product) overflows an int64.
+func MulAddChecked(acc, a, b int64) (int64, bool) {
+	if a == 0 || b == 0 {
+		return acc, true
+	}
+	prod := a * b
+	if prod/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return 0, false
+	}
+	return AddChecked(acc, prod)
+}
@@ -0,0 +1,35 @@
+package go2x3
+
+// NonBacktrackingProvider is optionally implemented by a TracesProvider able to count its non-backtracking closed walks
+// (closed walks that never immediately reverse the edge just traversed).
+//
+// These "non-backtracking Traces" are the traces of powers of the graph's Hashimoto (non-backtracking edge) matrix and so are
+// a second invariant family, able to separate some graphs whose (plain) Traces are the same.
+type NonBacktrackingProvider interface {
+	TracesProvider
+
+	// NonBacktrackingTraces returns the first numTraces non-backtracking Traces (0 denotes the vertex count).
+	// Returns ErrTracesOverflow if a term overflows an int64.
+	NonBacktrackingTraces(numTraces int) (Traces, error)
+}
+
+// NonBacktracking returns a TracesProvider whose Traces are the non-backtracking Traces of X, or nil if X is not a NonBacktrackingProvider.
+// This allows non-backtracking Traces to be used anywhere Traces are (e.g. as a catalog index, see IndexByNonBacktracking).
+func NonBacktracking(X TracesProvider) TracesProvider {
+	switch X := X.(type) {
+	case nonBacktracking:
+		return X
+	case NonBacktrackingProvider:
+		return nonBacktracking{X}
+	}
+	return nil
+}
+
+type nonBacktracking struct {
+	NonBacktrackingProvider
+}
+
+func (X nonBacktracking) Traces(numTraces int) Traces {
+	TX, _ := X.NonBacktrackingTraces(numTraces)
+	return TX
+}
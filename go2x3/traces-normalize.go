@@ -0,0 +1,181 @@
+package go2x3
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"sync"
+)
+
+// TracesNormalizer maps a graph's Traces onto a float vector, e.g. so that Traces of differing vertex count are comparable.
+//
+// Normalizers are registered by name (see RegisterNormalizer) so they can be selected from PrintOpts, CSV output and Python.
+type TracesNormalizer interface {
+
+	// Name uniquely identifies this normalizer (e.g. "pow3").
+	Name() string
+
+	// Desc is a one-line description of this normalization.
+	Desc() string
+
+	// AppendNormalized appends the normalization of TX, the Traces of a graph having Nv vertices, to out.
+	// One value is appended for each term of TX.
+	AppendNormalized(out []float64, TX Traces, Nv int) []float64
+}
+
+// NormalizerFunc is a TracesNormalizer defined by a function.
+type NormalizerFunc struct {
+	ID        string
+	About     string
+	Normalize func(out []float64, TX Traces, Nv int) []float64
+}
+
+func (norm *NormalizerFunc) Name() string {
+	return norm.ID
+}
+
+func (norm *NormalizerFunc) Desc() string {
+	return norm.About
+}
+
+func (norm *NormalizerFunc) AppendNormalized(out []float64, TX Traces, Nv int) []float64 {
+	return norm.Normalize(out, TX, Nv)
+}
+
+var (
+	normalizersMu sync.RWMutex
+	normalizers   = map[string]TracesNormalizer{}
+)
+
+// RegisterNormalizer makes the given TracesNormalizer available by name, replacing any normalizer of the same name.
+func RegisterNormalizer(norm TracesNormalizer) {
+	normalizersMu.Lock()
+	normalizers[norm.Name()] = norm
+	normalizersMu.Unlock()
+}
+
+// GetNormalizer returns the TracesNormalizer registered under the given name.
+func GetNormalizer(name string) (TracesNormalizer, error) {
+	normalizersMu.RLock()
+	norm := normalizers[name]
+	normalizersMu.RUnlock()
+	if norm == nil {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownNormalizer, name)
+	}
+	return norm, nil
+}
+
+// Normalizers returns all registered TracesNormalizers, sorted by name.
+func Normalizers() []TracesNormalizer {
+	normalizersMu.RLock()
+	list := make([]TracesNormalizer, 0, len(normalizers))
+	for _, norm := range normalizers {
+		list = append(list, norm)
+	}
+	normalizersMu.RUnlock()
+
+	slices.SortFunc(list, func(a, b TracesNormalizer) int {
+		return cmp.Compare(a.Name(), b.Name())
+	})
+	return list
+}
+
+// NormalizedTraces returns the first numTraces Traces of X (0 denotes X's vertex count) normalized by the named TracesNormalizer.
+func NormalizedTraces(X TracesProvider, numTraces int, name string) ([]float64, error) {
+	norm, err := GetNormalizer(name)
+	if err != nil {
+		return nil, err
+	}
+	return norm.AppendNormalized(nil, X.Traces(numTraces), X.VertexCount()), nil
+}
+
+// WriteNormalizedTraces writes a table of TX, the Traces of a graph having Nv vertices, alongside each of the named normalizations,
+// one row per Traces term.  If no names are given, all registered normalizers are written.
+func WriteNormalizedTraces(out io.Writer, TX Traces, Nv int, names ...string) error {
+	var norms []TracesNormalizer
+	if len(names) == 0 {
+		norms = Normalizers()
+	}
+	for _, name := range names {
+		norm, err := GetNormalizer(name)
+		if err != nil {
+			return err
+		}
+		norms = append(norms, norm)
+	}
+
+	cols := make([][]float64, len(norms))
+	for i, norm := range norms {
+		cols[i] = norm.AppendNormalized(nil, TX, Nv)
+	}
+
+	line := make([]byte, 0, 256)
+	line = fmt.Appendf(line, "%5s  %12s", "", "Ti")
+	for _, norm := range norms {
+		line = fmt.Appendf(line, "  %14s", norm.Name())
+	}
+	line = append(line, '\n')
+	for ti, Ti := range TX {
+		line = fmt.Appendf(line, "  C%02d  %12d", ti+1, Ti)
+		for _, col := range cols {
+			line = fmt.Appendf(line, "  %+14.6g", col[ti])
+		}
+		line = append(line, '\n')
+	}
+	_, err := out.Write(line)
+	return err
+}
+
+// AppendNormalizedCSV appends each of the named normalizations of TX as CSV columns (see PrintOpts.Normalize).
+func AppendNormalizedCSV(out []byte, TX Traces, Nv int, names []string) ([]byte, error) {
+	var scrap [32]float64
+	for _, name := range names {
+		norm, err := GetNormalizer(name)
+		if err != nil {
+			return out, err
+		}
+		for _, Ni := range norm.AppendNormalized(scrap[:0], TX, Nv) {
+			out = fmt.Appendf(out, "%.6g,", Ni)
+		}
+	}
+	return out, nil
+}
+
+func init() {
+	RegisterNormalizer(&NormalizerFunc{
+		ID:    "raw",
+		About: "Ti (no normalization)",
+		Normalize: func(out []float64, TX Traces, Nv int) []float64 {
+			for _, Ti := range TX {
+				out = append(out, float64(Ti))
+			}
+			return out
+		},
+	})
+
+	RegisterNormalizer(&NormalizerFunc{
+		ID:    "ci2",
+		About: "Ti / i^2, i.e. each term scaled by 1/Ci^2 (area packing)",
+		Normalize: func(out []float64, TX Traces, Nv int) []float64 {
+			for ti, Ti := range TX {
+				ci := float64(ti + 1)
+				out = append(out, float64(Ti)/(ci*ci))
+			}
+			return out
+		},
+	})
+
+	// Each vertex has at most 3 edges or loops, so |Ti| <= Nv * 3^i.
+	RegisterNormalizer(&NormalizerFunc{
+		ID:    "pow3",
+		About: "Ti / (Nv * 3^i), so each term is within -1..1",
+		Normalize: func(out []float64, TX Traces, Nv int) []float64 {
+			for ti, Ti := range TX {
+				out = append(out, float64(Ti)/(float64(max(Nv, 1))*math.Pow(3, float64(ti+1))))
+			}
+			return out
+		},
+	})
+}
@@ -0,0 +1,260 @@
+package go2x3
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TracesPredicate is a condition on a graph's Traces, such as "T1 == 0", "T2 % 9 == 0", "odd == 0" or "P4 > 0" (see GraphSelector.Predicates).
+type TracesPredicate interface {
+
+	// SelectsTraces returns true if TX meets this predicate.
+	// TX holds at least as many Traces as the graph's vertex count or NumTraces(), whichever is greater.
+	SelectsTraces(TX Traces) bool
+
+	// NumTraces returns the number of Traces this predicate needs (0 denotes the graph's vertex count).
+	NumTraces() int
+
+	// String returns this predicate in query form (see ParseQuery).
+	String() string
+}
+
+// CompareOp is a comparison used by a TracesPredicate.
+type CompareOp byte
+
+const (
+	OpEQ CompareOp = iota
+	OpNE
+	OpLT
+	OpLE
+	OpGT
+	OpGE
+)
+
+var compareOpStrs = []string{
+	OpEQ: "==",
+	OpNE: "!=",
+	OpLT: "<",
+	OpLE: "<=",
+	OpGT: ">",
+	OpGE: ">=",
+}
+
+func (op CompareOp) String() string {
+	if int(op) < len(compareOpStrs) {
+		return compareOpStrs[op]
+	}
+	return fmt.Sprintf("CompareOp(%d)", int(op))
+}
+
+// Compare returns the result of "a op b".
+func (op CompareOp) Compare(a, b int64) bool {
+	switch op {
+	case OpEQ:
+		return a == b
+	case OpNE:
+		return a != b
+	case OpLT:
+		return a < b
+	case OpLE:
+		return a <= b
+	case OpGT:
+		return a > b
+	case OpGE:
+		return a >= b
+	}
+	return false
+}
+
+// TermSet specifies which Traces terms a TracesPredicate tests when it has no Index.
+type TermSet byte
+
+const (
+	AllTerms  TermSet = iota // T1, T2, T3, ...
+	OddTerms                 // T1, T3, T5, ... (all zero for a boson)
+	EvenTerms                // T2, T4, T6, ...
+)
+
+var termSetStrs = []string{
+	AllTerms:  "all",
+	OddTerms:  "odd",
+	EvenTerms: "even",
+}
+
+func (terms TermSet) String() string {
+	if int(terms) < len(termSetStrs) {
+		return termSetStrs[terms]
+	}
+	return fmt.Sprintf("TermSet(%d)", int(terms))
+}
+
+// forEach calls test on each term of TX in this set, returning false as soon as test does.
+func (terms TermSet) forEach(TX Traces, test func(Ti int64) bool) bool {
+	start, step := 0, 1
+	switch terms {
+	case OddTerms:
+		step = 2
+	case EvenTerms:
+		start, step = 1, 2
+	}
+	for i := start; i < len(TX); i += step {
+		if !test(TX[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// TracesCond compares a Traces term with a value, e.g. "T2 >= 13".
+// If Index is 0, every term in Terms is compared, so "odd == 0" selects bosons and "all >= 0" selects graphs with no negative Traces.
+// If Primitive is set, the primitive Traces are compared instead, e.g. "P4 > 0" (see Traces.Primitive).
+type TracesCond struct {
+	Index     int     // one-based Traces index (T1 is TX[0]), or 0 to compare each term in Terms
+	Terms     TermSet // terms compared when Index is 0
+	Primitive bool    // if set, terms of the primitive Traces are compared
+	Op        CompareOp
+	Value     int64
+}
+
+func (cond TracesCond) String() string {
+	return fmt.Sprintf("%s%v%d", termName(cond.Index, cond.Terms, cond.Primitive), cond.Op, cond.Value)
+}
+
+func (cond TracesCond) NumTraces() int {
+	return cond.Index
+}
+
+func (cond TracesCond) SelectsTraces(TX Traces) bool {
+	if cond.Primitive {
+		TX = primitiveTo(TX, cond.Index)
+	}
+	if cond.Index == 0 {
+		return cond.Terms.forEach(TX, func(Ti int64) bool {
+			return cond.Op.Compare(Ti, cond.Value)
+		})
+	}
+	if cond.Index < 0 || cond.Index > len(TX) {
+		return false
+	}
+	return cond.Op.Compare(TX[cond.Index-1], cond.Value)
+}
+
+// TracesMod tests the remainder of a Traces term, e.g. "T2 % 9 == 0".
+// The remainder is taken to be non-negative, so "T1 % 3 == 1" is met by T1 = -2.
+// If Index is 0, every term in Terms is tested.  If Primitive is set, the primitive Traces are tested instead.
+type TracesMod struct {
+	Index     int     // one-based Traces index (T1 is TX[0]), or 0 to test each term in Terms
+	Terms     TermSet // terms tested when Index is 0
+	Primitive bool    // if set, terms of the primitive Traces are tested
+	Modulus   int64   // must be > 0
+	Remainder int64
+}
+
+func (mod TracesMod) String() string {
+	return fmt.Sprintf("%s%%%d==%d", termName(mod.Index, mod.Terms, mod.Primitive), mod.Modulus, mod.Remainder)
+}
+
+func (mod TracesMod) NumTraces() int {
+	return mod.Index
+}
+
+func (mod TracesMod) SelectsTraces(TX Traces) bool {
+	if mod.Modulus <= 0 {
+		return false
+	}
+	test := func(Ti int64) bool {
+		r := Ti % mod.Modulus
+		if r < 0 {
+			r += mod.Modulus
+		}
+		return r == mod.Remainder
+	}
+	if mod.Primitive {
+		TX = primitiveTo(TX, mod.Index)
+	}
+	if mod.Index == 0 {
+		return mod.Terms.forEach(TX, test)
+	}
+	if mod.Index < 0 || mod.Index > len(TX) {
+		return false
+	}
+	return test(TX[mod.Index-1])
+}
+
+// TracesNot selects Traces that do not meet all of its predicates, e.g. "!boson" or "!(T1 in 0..4)".
+type TracesNot []TracesPredicate
+
+func (not TracesNot) String() string {
+	strs := make([]string, len(not))
+	for i, pred := range not {
+		strs[i] = pred.String()
+	}
+	if len(strs) == 1 {
+		return "!" + strs[0]
+	}
+	return "!(" + strings.Join(strs, " && ") + ")"
+}
+
+func (not TracesNot) NumTraces() int {
+	numTraces := 0
+	for _, pred := range not {
+		numTraces = max(numTraces, pred.NumTraces())
+	}
+	return numTraces
+}
+
+func (not TracesNot) SelectsTraces(TX Traces) bool {
+	for _, pred := range not {
+		if !pred.SelectsTraces(TX) {
+			return true
+		}
+	}
+	return false
+}
+
+func termName(index int, terms TermSet, primitive bool) string {
+	switch {
+	case index == 0 && primitive:
+		return "p_" + terms.String()
+	case index == 0:
+		return terms.String()
+	case primitive:
+		return fmt.Sprintf("P%d", index)
+	}
+	return fmt.Sprintf("T%d", index)
+}
+
+// primitiveTo returns the primitive Traces of TX up to the given one-based index (0 denotes all of TX).
+func primitiveTo(TX Traces, index int) Traces {
+	if index > 0 && index < len(TX) {
+		TX = TX[:index]
+	}
+	return TX.Primitive()
+}
+
+// PredicateTraces returns the number of Traces needed to evaluate sel.Predicates for a graph having Nv vertices (at least Nv).
+func (sel *GraphSelector) PredicateTraces(Nv int) int {
+	numTraces := Nv
+	for _, pred := range sel.Predicates {
+		numTraces = max(numTraces, pred.NumTraces())
+	}
+	return numTraces
+}
+
+// SelectsTraces returns true if TX meets each of sel.Predicates (see TracesPredicate.SelectsTraces).
+func (sel *GraphSelector) SelectsTraces(TX Traces) bool {
+	for _, pred := range sel.Predicates {
+		if !pred.SelectsTraces(TX) {
+			return false
+		}
+	}
+	return true
+}
+
+// SelectsGraphTraces returns true if the Traces of X meet each of sel.Predicates.
+func (sel *GraphSelector) SelectsGraphTraces(X State) bool {
+	if len(sel.Predicates) == 0 {
+		return true
+	}
+	return sel.SelectsTraces(X.Traces(sel.PredicateTraces(X.VertexCount())))
+}
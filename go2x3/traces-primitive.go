@@ -0,0 +1,60 @@
+package go2x3
+
+// Each closed walk of length k is uniquely the (k/d)-fold repeat of a primitive closed walk of length d, where d divides k, so:
+//
+//	Tk = Σ Pd      for each d dividing k
+//
+// Möbius inversion then gives the primitive Traces ("C-prime" terms), each counting only closed walks not already
+// accounted for by a repeat of a shorter closed walk:
+//
+//	Pk = Σ μ(k/d) Td   for each d dividing k
+//
+// For example, P2 = T2 - T1, P4 = T4 - T2, and P6 = T6 - T3 - T2 + T1.
+// Since Pk counts every rotation of each primitive cycle of length k, Pk is divisible by k for a graph's Traces.
+
+// Primitive returns the primitive Traces of TX, where Pk counts the primitive closed walks of length k.
+// TX may also be a vertex's cycles vector (see VtxGroup.Cycles), giving the primitive closed walks through that vertex.
+func (TX Traces) Primitive() Traces {
+	prim := make(Traces, len(TX))
+	for k := 1; k <= len(TX); k++ {
+		Pk := int64(0)
+		for d := 1; d <= k; d++ {
+			if k%d == 0 {
+				Pk += int64(mobius(k/d)) * TX[d-1]
+			}
+		}
+		prim[k-1] = Pk
+	}
+	return prim
+}
+
+// FromPrimitive is the inverse of Primitive, returning the Traces whose primitive Traces are TX.
+func (TX Traces) FromPrimitive() Traces {
+	out := make(Traces, len(TX))
+	for k := 1; k <= len(TX); k++ {
+		for d := 1; d <= k; d++ {
+			if k%d == 0 {
+				out[k-1] += TX[d-1]
+			}
+		}
+	}
+	return out
+}
+
+// mobius returns the Möbius function μ(n): 0 if n has a squared prime factor, otherwise (-1)^(number of prime factors).
+func mobius(n int) int {
+	mu := 1
+	for p := 2; p*p <= n; p++ {
+		if n%p == 0 {
+			n /= p
+			if n%p == 0 {
+				return 0
+			}
+			mu = -mu
+		}
+	}
+	if n > 1 {
+		mu = -mu
+	}
+	return mu
+}
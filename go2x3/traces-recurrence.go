@@ -0,0 +1,169 @@
+package go2x3
+
+import (
+	"math/big"
+)
+
+// Since Traces are power sums of a graph's eigenvalues, they satisfy the linear recurrence given by its characteristic polynomial:
+//
+//	Tk = -(C1 T(k-1) + C2 T(k-2) + ... + Cn T(k-n))    for k > n
+//
+// So once the first Nv Traces are known, any number of Traces follow with Nv multiply-adds per term
+// (versus a pass over every vertex edge per term).
+
+// ExtendTraces returns TX extended to numTraces terms using the linear recurrence given by P, where TX holds at least P.Degree() terms.
+// Returns ErrTracesOverflow if a term overflows an int64 (see ExtendBigTraces).
+func (P CharPoly) ExtendTraces(TX Traces, numTraces int) (Traces, error) {
+	n := P.Degree()
+	if len(TX) < n {
+		return nil, ErrInsufficientTraces
+	}
+	if numTraces <= len(TX) {
+		return TX[:max(numTraces, 0)], nil
+	}
+
+	out := make(Traces, numTraces)
+	copy(out, TX)
+	for k := len(TX); k < numTraces; k++ {
+		var Tk int64
+		var ok bool
+		for i := 1; i <= n; i++ {
+			if Tk, ok = MulAddChecked(Tk, -P[i], out[k-i]); !ok {
+				return nil, ErrTracesOverflow
+			}
+		}
+		out[k] = Tk
+	}
+	return out, nil
+}
+
+// ExtendBigTraces is ExtendTraces using arbitrary-precision integers, so any number of exact Traces can be extrapolated.
+func (P CharPoly) ExtendBigTraces(TX BigTraces, numTraces int) (BigTraces, error) {
+	n := P.Degree()
+	if len(TX) < n {
+		return nil, ErrInsufficientTraces
+	}
+	if numTraces <= len(TX) {
+		return TX[:max(numTraces, 0)], nil
+	}
+
+	coeffs := make([]big.Int, n+1)
+	for i := range coeffs {
+		coeffs[i].SetInt64(-P[i])
+	}
+
+	out := make(BigTraces, numTraces)
+	copy(out, TX)
+	var term big.Int
+	for k := len(TX); k < numTraces; k++ {
+		Tk := new(big.Int)
+		for i := 1; i <= n; i++ {
+			Tk.Add(Tk, term.Mul(&coeffs[i], out[k-i]))
+		}
+		out[k] = Tk
+	}
+	return out, nil
+}
+
+// ExtendedTraces returns the first numTraces Traces of X by computing its first Nv Traces directly and extending them
+// using the recurrence given by its characteristic polynomial (see GraphCharPoly).
+// The result matches X.Traces(numTraces), but is cheap to compute for long Traces.
+func ExtendedTraces(X TracesProvider, numTraces int) (Traces, error) {
+	Nv := X.VertexCount()
+	if numTraces <= Nv {
+		return CheckedTraces(X, numTraces)
+	}
+	TX, err := CheckedTraces(X, Nv)
+	if err != nil {
+		return nil, err
+	}
+	P, err := CharPolyOf(TX, Nv)
+	if err != nil {
+		return nil, err
+	}
+	return P.ExtendTraces(TX, numTraces)
+}
+
+// ExtendedBigTraces is ExtendedTraces using arbitrary-precision integers, so it never overflows.
+func ExtendedBigTraces(X TracesProvider, numTraces int) (BigTraces, error) {
+	Nv := X.VertexCount()
+	var TX BigTraces
+	if exact, ok := X.(ExactTracesProvider); ok {
+		TX = exact.BigTraces(Nv)
+	} else {
+		TX = make(BigTraces, Nv)
+		for i, Ti := range X.Traces(Nv) {
+			TX[i] = big.NewInt(Ti)
+		}
+	}
+	P, err := charPolyOf(TX, Nv)
+	if err != nil {
+		return nil, err
+	}
+	return P.ExtendBigTraces(TX, numTraces)
+}
+
+// FindRecurrence returns the shortest linear recurrence satisfied by TX (using the Berlekamp-Massey algorithm), for when only
+// Traces are known.  The recurrence is returned as a polynomial whose roots are the distinct non-zero eigenvalues of the graph
+// contributing to TX, so its degree may be less than the graph's vertex count (see CharPoly.ExtendTraces).
+//
+// TX must hold at least twice as many terms as the degree of the recurrence found, otherwise ErrInsufficientTraces is returned.
+// ErrNotCharPoly is returned if the recurrence does not have integer coefficients.
+func FindRecurrence(TX Traces) (CharPoly, error) {
+	s := make([]big.Rat, len(TX))
+	for i, Ti := range TX {
+		s[i].SetInt64(Ti)
+	}
+
+	// C is the current connection polynomial and B is C prior to the last length change
+	C := []big.Rat{*big.NewRat(1, 1)}
+	B := []big.Rat{*big.NewRat(1, 1)}
+	L, m := 0, 1
+	b := big.NewRat(1, 1)
+
+	var d, coef, term big.Rat
+	for n := range s {
+		d.Set(&s[n])
+		for i := 1; i <= L; i++ {
+			d.Add(&d, term.Mul(&C[i], &s[n-i]))
+		}
+		if d.Sign() == 0 {
+			m++
+			continue
+		}
+
+		prevC := make([]big.Rat, len(C))
+		for i := range C {
+			prevC[i].Set(&C[i])
+		}
+		coef.Quo(&d, b)
+		for len(C) < len(B)+m {
+			C = append(C, big.Rat{})
+		}
+		for i := range B {
+			C[i+m].Sub(&C[i+m], term.Mul(&coef, &B[i]))
+		}
+		if 2*L <= n {
+			L = n + 1 - L
+			B = prevC
+			b = new(big.Rat).Set(&d)
+			m = 1
+		} else {
+			m++
+		}
+	}
+
+	if 2*L > len(TX) {
+		return nil, ErrInsufficientTraces
+	}
+	P := make(CharPoly, L+1)
+	for i := range P {
+		if i < len(C) {
+			if !C[i].IsInt() || !C[i].Num().IsInt64() {
+				return nil, ErrNotCharPoly
+			}
+			P[i] = C[i].Num().Int64()
+		}
+	}
+	return P, nil
+}
@@ -0,0 +1,478 @@
+package go2x3
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"math/cmplx"
+	"slices"
+	"strconv"
+)
+
+// Traces are the power sums of a graph's adjacency eigenvalues (Ti = sum of λ^i), so by Newton's identities a graph's
+// first Nv Traces determine its characteristic polynomial, and vice versa.
+
+// CharPoly holds the integer coefficients of a characteristic polynomial, det(xI - A) = x^n + C1 x^(n-1) + ... + Cn,
+// where CharPoly[i] = Ci and CharPoly[0] = 1.
+type CharPoly []int64
+
+// Degree returns n, the degree of this polynomial (the vertex count of its graph).
+func (P CharPoly) Degree() int {
+	return len(P) - 1
+}
+
+// CharPolyOf returns the characteristic polynomial of a graph having Nv vertices from its Traces, which must hold at least Nv terms.
+//
+// Returns ErrInsufficientTraces if TX has fewer than Nv terms, ErrNotCharPoly if TX are not the Traces of an integer matrix,
+// or ErrTracesOverflow if a coefficient overflows an int64.
+func CharPolyOf(TX Traces, Nv int) (CharPoly, error) {
+	if len(TX) < Nv {
+		return nil, ErrInsufficientTraces
+	}
+	TXbig := make(BigTraces, Nv)
+	for i := range TXbig {
+		TXbig[i] = big.NewInt(TX[i])
+	}
+	return charPolyOf(TXbig, Nv)
+}
+
+// GraphCharPoly returns the characteristic polynomial of X, using exact Traces if X is an ExactTracesProvider whose Traces overflow.
+func GraphCharPoly(X TracesProvider) (CharPoly, error) {
+	Nv := X.VertexCount()
+	TX, err := CheckedTraces(X, Nv)
+	if err == nil {
+		return CharPolyOf(TX, Nv)
+	}
+	if exact, ok := X.(ExactTracesProvider); ok && errors.Is(err, ErrTracesOverflow) {
+		return charPolyOf(exact.BigTraces(Nv), Nv)
+	}
+	return nil, err
+}
+
+// charPolyOf applies Newton's identities, k*Ek = Σ (-1)^(i-1) E(k-i) Ti (for i = 1..k), where Ck = (-1)^k Ek.
+func charPolyOf(TX BigTraces, Nv int) (CharPoly, error) {
+	if len(TX) < Nv {
+		return nil, ErrInsufficientTraces
+	}
+
+	E := make([]big.Int, Nv+1)
+	E[0].SetInt64(1)
+
+	var sum, term, rem, bigK big.Int
+	for k := 1; k <= Nv; k++ {
+		sum.SetInt64(0)
+		for i := 1; i <= k; i++ {
+			term.Mul(&E[k-i], TX[i-1])
+			if i&1 == 1 {
+				sum.Add(&sum, &term)
+			} else {
+				sum.Sub(&sum, &term)
+			}
+		}
+		bigK.SetInt64(int64(k))
+		E[k].QuoRem(&sum, &bigK, &rem)
+		if rem.Sign() != 0 {
+			return nil, ErrNotCharPoly
+		}
+	}
+
+	P := make(CharPoly, Nv+1)
+	for k := range P {
+		if !E[k].IsInt64() {
+			return nil, ErrTracesOverflow
+		}
+		P[k] = E[k].Int64()
+		if k&1 == 1 {
+			P[k] = -P[k]
+		}
+	}
+	return P, nil
+}
+
+// Traces returns the first numTraces Traces (0 denotes the degree) of a graph having this characteristic polynomial,
+// or ErrTracesOverflow if a term overflows an int64.
+func (P CharPoly) Traces(numTraces int) (Traces, error) {
+	n := P.Degree()
+	if numTraces <= 0 {
+		numTraces = n
+	}
+
+	// Newton's identities: Tk + C1 T(k-1) + ... + C(k-1) T1 + k Ck = 0, where Ck = 0 for k > n
+	TX := make(Traces, numTraces)
+	for k := 1; k <= numTraces; k++ {
+		var Tk int64
+		var ok bool
+		if k <= n {
+			if Tk, ok = MulAddChecked(0, int64(k), P[k]); !ok {
+				return nil, ErrTracesOverflow
+			}
+		}
+		for i := 1; i < k && i <= n; i++ {
+			if Tk, ok = MulAddChecked(Tk, P[i], TX[k-i-1]); !ok {
+				return nil, ErrTracesOverflow
+			}
+		}
+		if Tk == math.MinInt64 {
+			return nil, ErrTracesOverflow
+		}
+		TX[k-1] = -Tk
+	}
+	return TX, nil
+}
+
+// Eval returns the value of this polynomial at x.
+func (P CharPoly) Eval(x complex128) complex128 {
+	val := complex(0, 0)
+	for _, Ci := range P {
+		val = val*x + complex(float64(Ci), 0)
+	}
+	return val
+}
+
+// String returns this polynomial in text form, e.g. "x^3 - 5x^2 + 6x".
+func (P CharPoly) String() string {
+	n := P.Degree()
+	var str []byte
+	for i, Ci := range P {
+		if Ci == 0 {
+			continue
+		}
+		if len(str) > 0 {
+			if Ci < 0 {
+				str = append(str, " - "...)
+			} else {
+				str = append(str, " + "...)
+			}
+		} else if Ci < 0 {
+			str = append(str, '-')
+		}
+		absCi := absInt64(Ci)
+		if absCi != 1 || i == n {
+			str = strconv.AppendInt(str, absCi, 10)
+		}
+		switch pow := n - i; {
+		case pow == 1:
+			str = append(str, 'x')
+		case pow > 1:
+			str = append(str, "x^"...)
+			str = strconv.AppendInt(str, int64(pow), 10)
+		}
+	}
+	if len(str) == 0 {
+		return "0"
+	}
+	return string(str)
+}
+
+// Roots numerically computes the roots of this polynomial, each repeated by its multiplicity.
+//
+// Since P has integer coefficients, it is first factored exactly into square-free factors (see squareFreeFactors), so each root
+// is found as a simple root of a factor (using the Durand-Kerner method and then Newton's method), keeping repeated roots as precise as any other.
+// Returns ErrInexactSpectrum if a root's residual exceeds rootTolerance.
+func (P CharPoly) Roots() ([]complex128, error) {
+	if P.Degree() <= 0 {
+		return nil, nil
+	}
+
+	roots := make([]complex128, 0, P.Degree())
+	for mult, factor := range squareFreeFactors(P) {
+		if factor == nil {
+			continue
+		}
+		coeffs := factor.floats()
+		factorRoots := simpleRoots(coeffs)
+		for _, root := range factorRoots {
+			if residual := relResidual(coeffs, root); residual > rootTolerance {
+				return nil, fmt.Errorf("%w: root %v of %v has residual %.3g", ErrInexactSpectrum, root, P, residual)
+			}
+			for range mult {
+				roots = append(roots, root)
+			}
+		}
+	}
+	return roots, nil
+}
+
+// rootTolerance is the largest relative residual (and relative imaginary part in Spectrum) accepted of a computed root.
+const rootTolerance = 1e-9
+
+// Spectrum returns the eigenvalues of a graph having this characteristic polynomial, in descending order.
+//
+// A 2x3 graph is undirected and so has a symmetric adjacency matrix whose eigenvalues are real, so ErrInexactSpectrum
+// is returned if a root has a non-negligible imaginary part (i.e. P is not the characteristic polynomial of a symmetric matrix).
+func (P CharPoly) Spectrum() ([]float64, error) {
+	roots, err := P.Roots()
+	if err != nil {
+		return nil, err
+	}
+	spectrum := make([]float64, len(roots))
+	for i, root := range roots {
+		if math.Abs(imag(root)) > rootTolerance*max(1, cmplx.Abs(root)) {
+			return nil, fmt.Errorf("%w: %v has complex root %v", ErrInexactSpectrum, P, root)
+		}
+		spectrum[i] = real(root)
+	}
+	slices.SortFunc(spectrum, func(a, b float64) int {
+		return cmp.Compare(b, a)
+	})
+	return spectrum, nil
+}
+
+// SpectralRadius returns the largest eigenvalue magnitude of a graph having this characteristic polynomial.
+func (P CharPoly) SpectralRadius() (float64, error) {
+	roots, err := P.Roots()
+	if err != nil {
+		return 0, err
+	}
+	radius := 0.0
+	for _, root := range roots {
+		radius = max(radius, cmplx.Abs(root))
+	}
+	return radius, nil
+}
+
+// simpleRoots computes the roots of the monic polynomial having the given coefficients (highest degree first), whose roots are distinct.
+func simpleRoots(coeffs []float64) []complex128 {
+	n := len(coeffs) - 1
+	if n <= 0 {
+		return nil
+	}
+	if n == 1 {
+		return []complex128{complex(-coeffs[1], 0)}
+	}
+
+	// All roots lie within this radius (Cauchy's bound)
+	bound := 0.0
+	for _, Ci := range coeffs[1:] {
+		bound = max(bound, math.Abs(Ci))
+	}
+	bound += 1
+
+	roots := make([]complex128, n)
+	seed := complex(0.4, 0.9)
+	for i := range roots {
+		roots[i] = complex(bound, 0) * cmplx.Pow(seed, complex(float64(i), 0)) / complex(cmplx.Abs(seed), 0)
+	}
+
+	const maxIters = 1000
+	for iter := 0; iter < maxIters; iter++ {
+		maxDelta := 0.0
+		for i, xi := range roots {
+			denom := complex(1, 0)
+			for j, xj := range roots {
+				if j != i {
+					denom *= xi - xj
+				}
+			}
+			if denom == 0 {
+				denom = complex(1e-12, 0)
+			}
+			val, _ := evalFloats(coeffs, xi)
+			delta := val / denom
+			roots[i] = xi - delta
+			maxDelta = max(maxDelta, cmplx.Abs(delta))
+		}
+		if maxDelta < 1e-14*bound {
+			break
+		}
+	}
+
+	// polish each root with Newton's method, keeping only steps that improve it
+	for i, xi := range roots {
+		for iter := 0; iter < 4; iter++ {
+			val, deriv := evalFloats(coeffs, xi)
+			if deriv == 0 {
+				break
+			}
+			next := xi - val/deriv
+			if nextVal, _ := evalFloats(coeffs, next); cmplx.Abs(nextVal) >= cmplx.Abs(val) {
+				break
+			}
+			xi = next
+		}
+		roots[i] = xi
+	}
+	return roots
+}
+
+// evalFloats returns the value and derivative at x of the polynomial having the given coefficients (highest degree first).
+func evalFloats(coeffs []float64, x complex128) (val, deriv complex128) {
+	for _, Ci := range coeffs {
+		deriv = deriv*x + val
+		val = val*x + complex(Ci, 0)
+	}
+	return val, deriv
+}
+
+// relResidual returns |p(x)| relative to the magnitude of the terms summed to evaluate it, where p has the given coefficients.
+func relResidual(coeffs []float64, x complex128) float64 {
+	val, _ := evalFloats(coeffs, x)
+	scale, absX := 0.0, cmplx.Abs(x)
+	for _, Ci := range coeffs {
+		scale = scale*absX + math.Abs(Ci)
+	}
+	if scale == 0 {
+		return 0
+	}
+	return cmplx.Abs(val) / scale
+}
+
+// ratPoly is a polynomial having rational coefficients, highest degree first, where the leading coefficient is non-zero
+// (so the zero polynomial is empty).
+type ratPoly []*big.Rat
+
+func (p ratPoly) degree() int {
+	return len(p) - 1
+}
+
+// trim drops leading zero coefficients.
+func (p ratPoly) trim() ratPoly {
+	for len(p) > 0 && p[0].Sign() == 0 {
+		p = p[1:]
+	}
+	return p
+}
+
+// monic returns p divided by its leading coefficient.
+func (p ratPoly) monic() ratPoly {
+	if len(p) == 0 {
+		return p
+	}
+	lead := new(big.Rat).Set(p[0])
+	out := make(ratPoly, len(p))
+	for i, Ci := range p {
+		out[i] = new(big.Rat).Quo(Ci, lead)
+	}
+	return out
+}
+
+func (p ratPoly) deriv() ratPoly {
+	n := p.degree()
+	if n <= 0 {
+		return nil
+	}
+	out := make(ratPoly, n)
+	for i := range out {
+		out[i] = new(big.Rat).Mul(p[i], new(big.Rat).SetInt64(int64(n-i)))
+	}
+	return out.trim()
+}
+
+func (p ratPoly) sub(q ratPoly) ratPoly {
+	n := max(len(p), len(q))
+	out := make(ratPoly, n)
+	for i := range out {
+		out[i] = new(big.Rat)
+		if j := i - (n - len(p)); j >= 0 {
+			out[i].Set(p[j])
+		}
+		if j := i - (n - len(q)); j >= 0 {
+			out[i].Sub(out[i], q[j])
+		}
+	}
+	return out.trim()
+}
+
+// divMod returns the quotient and remainder of p / q, where q is non-zero.
+func (p ratPoly) divMod(q ratPoly) (quo, rem ratPoly) {
+	rem = make(ratPoly, len(p))
+	for i, Ci := range p {
+		rem[i] = new(big.Rat).Set(Ci)
+	}
+	if len(p) < len(q) {
+		return nil, rem
+	}
+	quo = make(ratPoly, len(p)-len(q)+1)
+	var term big.Rat
+	for i := range quo {
+		quo[i] = new(big.Rat).Quo(rem[i], q[0])
+		for j, Qj := range q {
+			term.Mul(quo[i], Qj)
+			rem[i+j].Sub(rem[i+j], &term)
+		}
+	}
+	return quo.trim(), rem[len(quo):].trim()
+}
+
+// ratPolyGCD returns the monic greatest common divisor of p and q.
+func ratPolyGCD(p, q ratPoly) ratPoly {
+	for len(q) > 0 {
+		_, rem := p.divMod(q)
+		p, q = q, rem.monic()
+	}
+	return p.monic()
+}
+
+// floats returns the coefficients of p as float64s.
+func (p ratPoly) floats() []float64 {
+	out := make([]float64, len(p))
+	for i, Ci := range p {
+		out[i], _ = Ci.Float64()
+	}
+	return out
+}
+
+// squareFreeFactors factors P = Π factors[i]^i using Yun's algorithm (exact, in rational arithmetic), where each factor is monic
+// and has distinct roots (or is nil if P has no roots of that multiplicity).
+func squareFreeFactors(P CharPoly) []ratPoly {
+	f := make(ratPoly, len(P))
+	for i, Ci := range P {
+		f[i] = new(big.Rat).SetInt64(Ci)
+	}
+	f = f.trim().monic()
+
+	factors := []ratPoly{nil}
+	fd := f.deriv()
+	a := ratPolyGCD(f, fd)
+	b, _ := f.divMod(a)
+	c, _ := fd.divMod(a)
+	d := c.sub(b.deriv())
+	for b.degree() > 0 {
+		a = ratPolyGCD(b, d)
+		if a.degree() > 0 {
+			factors = append(factors, a)
+		} else {
+			factors = append(factors, nil)
+		}
+		b, _ = b.divMod(a)
+		c, _ = d.divMod(a)
+		d = c.sub(b.deriv())
+	}
+	return factors
+}
+
+// Spectrum returns the adjacency eigenvalues of X in descending order (see CharPoly.Spectrum).
+func Spectrum(X TracesProvider) ([]float64, error) {
+	P, err := GraphCharPoly(X)
+	if err != nil {
+		return nil, err
+	}
+	return P.Spectrum()
+}
+
+// SpectralRadius returns the largest adjacency eigenvalue magnitude of X.
+func SpectralRadius(X TracesProvider) (float64, error) {
+	P, err := GraphCharPoly(X)
+	if err != nil {
+		return 0, err
+	}
+	return P.SpectralRadius()
+}
+
+// IsCospectral returns true if X and Y have the same characteristic polynomial (and so the same Traces).
+func IsCospectral(X, Y TracesProvider) (bool, error) {
+	if X.VertexCount() != Y.VertexCount() {
+		return false, nil
+	}
+	PX, err := GraphCharPoly(X)
+	if err != nil {
+		return false, err
+	}
+	PY, err := GraphCharPoly(Y)
+	if err != nil {
+		return false, err
+	}
+	return slices.Equal(PX, PY), nil
+}
@@ -0,0 +1,141 @@
+package go2x3
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+)
+
+// Traces only keep the diagonal of each power of a graph's adjacency matrix.  A WalkMatrix keeps all of A^k, so the flow of
+// walks between vertices (or vertex groups) can be studied, not just the closed walks returning to where they started.
+
+// WalkMatrix holds the walk counts of a graph for walks of length K, where Walks[i][j] is the (signed) number of walks of
+// length K from vertex j to vertex i, i.e. (A^K)[i][j].  The trace of a WalkMatrix is the graph's Traces term TK.
+type WalkMatrix struct {
+	K      int       `json:"k"`
+	Labels []string  `json:"labels"` // names each row (and column)
+	Walks  [][]int64 `json:"walks"`
+}
+
+// WalkMatrixProvider is optionally implemented by a State able to return its full walk-count matrices.
+type WalkMatrixProvider interface {
+
+	// WalkMatrices returns A^1 .. A^numTraces (0 denotes the vertex count), either per vertex or, if byGroup is set,
+	// per consolidated vertex group (vertices having the same cycles vector).
+	WalkMatrices(numTraces int, byGroup bool) ([]WalkMatrix, error)
+}
+
+// WalkMatricesOf returns A^1 .. A^numTraces (0 denotes Nv) of the Nv x Nv matrix A (stored row-major), where each row and column
+// is named by the given labels.  Returns ErrTracesOverflow if a walk count overflows an int64.
+func WalkMatricesOf(A []int64, Nv int, numTraces int, labels []string) ([]WalkMatrix, error) {
+	if numTraces <= 0 {
+		numTraces = Nv
+	}
+
+	Ms := make([]WalkMatrix, numTraces)
+	prev := make([][]int64, Nv) // A^0, the identity
+	for i := range prev {
+		prev[i] = make([]int64, Nv)
+		prev[i][i] = 1
+	}
+
+	for k := range Ms {
+		walks := make([][]int64, Nv)
+		for i := range walks {
+			row := make([]int64, Nv)
+			for j := range row {
+				var ok bool
+				for m := 0; m < Nv; m++ {
+					if Aim := A[i*Nv+m]; Aim != 0 {
+						if row[j], ok = MulAddChecked(row[j], Aim, prev[m][j]); !ok {
+							return nil, ErrTracesOverflow
+						}
+					}
+				}
+			}
+			walks[i] = row
+		}
+		Ms[k] = WalkMatrix{
+			K:      k + 1,
+			Labels: labels,
+			Walks:  walks,
+		}
+		prev = walks
+	}
+	return Ms, nil
+}
+
+// Trace returns the sum of the diagonal of this WalkMatrix (the number of closed walks of length K).
+func (M *WalkMatrix) Trace() int64 {
+	sum := int64(0)
+	for i, row := range M.Walks {
+		sum += row[i]
+	}
+	return sum
+}
+
+// Grouped returns this WalkMatrix with its rows and columns summed by group, where groupOf[i] is the (zero-based) group of
+// row i and labels names each group.  So Walks[a][b] of the result is the number of walks from any vertex in group b
+// to any vertex in group a.
+func (M *WalkMatrix) Grouped(groupOf []int, labels []string) (WalkMatrix, error) {
+	Ng := len(labels)
+	walks := make([][]int64, Ng)
+	for a := range walks {
+		walks[a] = make([]int64, Ng)
+	}
+	for i, row := range M.Walks {
+		a := groupOf[i]
+		for j, Wij := range row {
+			var ok bool
+			b := groupOf[j]
+			if walks[a][b], ok = AddChecked(walks[a][b], Wij); !ok {
+				return WalkMatrix{}, ErrTracesOverflow
+			}
+		}
+	}
+	return WalkMatrix{
+		K:      M.K,
+		Labels: labels,
+		Walks:  walks,
+	}, nil
+}
+
+// AppendCSV appends this WalkMatrix as CSV rows of the form "k,label,W[i][0],W[i][1],...", optionally preceded by a header row.
+func (M *WalkMatrix) AppendCSV(out []byte, header bool) []byte {
+	if header {
+		out = append(out, "k,to\\from"...)
+		for _, label := range M.Labels {
+			out = append(out, ',')
+			out = append(out, label...)
+		}
+		out = append(out, '\n')
+	}
+	for i, row := range M.Walks {
+		out = strconv.AppendInt(out, int64(M.K), 10)
+		out = append(out, ',')
+		out = append(out, M.Labels[i]...)
+		for _, Wij := range row {
+			out = append(out, ',')
+			out = strconv.AppendInt(out, Wij, 10)
+		}
+		out = append(out, '\n')
+	}
+	return out
+}
+
+// WriteWalkMatricesCSV writes the given WalkMatrices as CSV (see WalkMatrix.AppendCSV), with a single header row.
+func WriteWalkMatricesCSV(out io.Writer, Ms []WalkMatrix) error {
+	var line []byte
+	for i := range Ms {
+		line = Ms[i].AppendCSV(line[:0], i == 0)
+		if _, err := out.Write(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteWalkMatricesJSON writes the given WalkMatrices as a JSON array of {"k", "labels", "walks"} objects.
+func WriteWalkMatricesJSON(out io.Writer, Ms []WalkMatrix) error {
+	return json.NewEncoder(out).Encode(Ms)
+}
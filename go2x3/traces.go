@@ -42,6 +42,16 @@ func (TX Traces) IsEqual(target Traces) bool {
 	return true
 }
 
+// IsBoson returns true if all odd traces (TX[0], TX[2], ...) are zero.
+func (TX Traces) IsBoson() bool {
+	for i := 0; i < len(TX); i += 2 {
+		if TX[i] != 0 {
+			return false
+		}
+	}
+	return true
+}
+
 // IsZero() returns true if all values of this Traces are 0.
 func (TX Traces) IsZero() bool {
 	for _, TXi := range TX {
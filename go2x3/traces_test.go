@@ -1,6 +1,9 @@
 package go2x3
 
 import (
+	"errors"
+	"math"
+	"strings"
 	"testing"
 )
 
@@ -36,3 +39,312 @@ func checkEncoding(TX Traces, scrap []byte) {
 	}
 
 }
+
+func TestCheckedMath(t *testing.T) {
+	const maxInt = int64(^uint64(0) >> 1)
+
+	if sum, ok := AddChecked(maxInt-1, 1); !ok || sum != maxInt {
+		t.Fatal("AddChecked failed")
+	}
+	if _, ok := AddChecked(maxInt, 1); ok {
+		t.Fatal("AddChecked should overflow")
+	}
+	if _, ok := AddChecked(-maxInt, -2); ok {
+		t.Fatal("AddChecked should underflow")
+	}
+	if v, ok := MulAddChecked(7, -3, 5); !ok || v != -8 {
+		t.Fatal("MulAddChecked failed")
+	}
+	if _, ok := MulAddChecked(0, maxInt/2, 3); ok {
+		t.Fatal("MulAddChecked should overflow")
+	}
+	if _, ok := MulAddChecked(maxInt, 1, 1); ok {
+		t.Fatal("MulAddChecked sum should overflow")
+	}
+	if n := NumExactTraces(1, 3); n != 39 {
+		t.Fatalf("NumExactTraces: expected 39, got %d", n)
+	}
+	if n := NumExactTraces(4, 1); n != math.MaxInt {
+		t.Fatalf("NumExactTraces: expected no bound, got %d", n)
+	}
+}
+
+func TestBigTracesOf(t *testing.T) {
+	// A graph of one vertex with a weight of 3 has Ti = 3^i, which overflows an int64 at C40
+	TX := BigTracesOf([]int64{3}, 1, 48)
+	if len(TX) != 48 || TX[0].Int64() != 3 || TX[1].Int64() != 9 {
+		t.Fatalf("unexpected Traces: %v", TX)
+	}
+	if TX[47].String() != "79766443076872509863361" {
+		t.Fatalf("C48: got %v", TX[47])
+	}
+	if _, err := TX.Traces(); err != ErrTracesOverflow {
+		t.Fatal("expected ErrTracesOverflow")
+	}
+	if TX39, err := TX[:39].Traces(); err != nil || TX39[38] != 4052555153018976267 {
+		t.Fatalf("C39: got %v (%v)", TX39, err)
+	}
+
+	// 1-2 with a loop on 2: A = [[0,1],[1,1]], so Ti are the Lucas numbers 1, 3, 4, 7, 11, ...
+	TX = BigTracesOf([]int64{0, 1, 1, 1}, 2, 6)
+	if TX.String() != "1,3,4,7,11,18" {
+		t.Fatalf("got %v", TX)
+	}
+}
+
+func TestTracesAlgebra(t *testing.T) {
+	proton := Traces{5, 13, 35}
+	anti := proton.NegateOdd()
+	if anti.String() != "-5,13,-35" {
+		t.Fatalf("NegateOdd: got %v", anti)
+	}
+	if sum := proton.Add(anti); sum.String() != "0,26,0" || !sum.IsBoson() {
+		t.Fatalf("Add: got %v", sum)
+	}
+	if diff := proton.Sub(proton); !diff.IsZero() {
+		t.Fatalf("Sub: got %v", diff)
+	}
+	if scaled := proton.Scale(2); !scaled.IsEqual(proton.Add(proton)) {
+		t.Fatalf("Scale: got %v", scaled)
+	}
+	if dot := proton.Dot(Traces{1, 1, 1, 1}); dot != 53 {
+		t.Fatalf("Dot: got %v", dot)
+	}
+
+	if proton.Compare(anti) <= 0 || anti.Compare(proton) >= 0 || proton.Compare(proton) != 0 {
+		t.Fatal("Compare failed")
+	}
+	if proton.Compare(proton[:2]) <= 0 {
+		t.Fatal("Compare: expected a prefix to sort first")
+	}
+	if proton.CompareMagnitude(anti) != 0 || proton.CompareMagnitude(Traces{5, -14}) >= 0 {
+		t.Fatal("CompareMagnitude failed")
+	}
+
+	for _, str := range []string{"5,13,35", "(5, 13, 35)", "[5 13 35]", " 5,13, 35 "} {
+		TX, err := ParseTraces(str)
+		if err != nil || !TX.IsEqual(proton) || len(TX) != 3 {
+			t.Fatalf("ParseTraces(%q): got %v (%v)", str, TX, err)
+		}
+	}
+	if _, err := ParseTraces("5,x"); err == nil {
+		t.Fatal("expected ParseTraces to fail")
+	}
+}
+
+func TestNormalizers(t *testing.T) {
+	names := ""
+	for _, norm := range Normalizers() {
+		names += norm.Name() + " "
+	}
+	if names != "ci2 pow3 raw " {
+		t.Fatalf("unexpected normalizers: %q", names)
+	}
+
+	proton := Traces{5, 13, 35}
+	normTX, err := NormalizedTraces(proton, 0, "pow3")
+	if err != nil || len(normTX) != 3 || normTX[0] != 5.0/9 || normTX[2] != 35.0/81 {
+		t.Fatalf("pow3: got %v (%v)", normTX, err)
+	}
+
+	line, err := AppendNormalizedCSV(nil, proton, 3, []string{"raw", "ci2"})
+	if err != nil || string(line) != "5,13,35,5,3.25,3.88889," {
+		t.Fatalf("AppendNormalizedCSV: got %q (%v)", line, err)
+	}
+
+	if _, err := GetNormalizer("bogus"); !errors.Is(err, ErrUnknownNormalizer) {
+		t.Fatal("expected ErrUnknownNormalizer")
+	}
+	var table strings.Builder
+	if err := WriteNormalizedTraces(&table, proton, 3, "raw", "bogus"); !errors.Is(err, ErrUnknownNormalizer) {
+		t.Fatal("expected ErrUnknownNormalizer")
+	}
+}
+
+func TestCharPoly(t *testing.T) {
+	// 1-2-3 has eigenvalues 3, 2, 0
+	proton := Traces{5, 13, 35}
+	P, err := CharPolyOf(proton, 3)
+	if err != nil || P.String() != "x^3 - 5x^2 + 6x" {
+		t.Fatalf("CharPolyOf: got %v (%v)", P, err)
+	}
+	TX, err := P.Traces(6)
+	if err != nil || TX.String() != "5,13,35,97,275,793" {
+		t.Fatalf("CharPoly.Traces: got %v (%v)", TX, err)
+	}
+
+	spectrum, err := P.Spectrum()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []float64{3, 2, 0} {
+		if math.Abs(spectrum[i]-want) > 1e-9 {
+			t.Fatalf("Spectrum: got %v", spectrum)
+		}
+	}
+	if radius, err := SpectralRadius(proton); err != nil || math.Abs(radius-3) > 1e-9 {
+		t.Fatalf("SpectralRadius: got %v (%v)", radius, err)
+	}
+
+	// Lucas numbers are the Traces of [[0,1],[1,1]], whose characteristic polynomial is x^2 - x - 1 (roots φ and -1/φ)
+	P, err = CharPolyOf(Traces{1, 3, 4, 7}, 2)
+	if err != nil || P.String() != "x^2 - x - 1" {
+		t.Fatalf("CharPolyOf: got %v (%v)", P, err)
+	}
+	if radius, err := P.SpectralRadius(); err != nil || math.Abs(radius-math.Phi) > 1e-9 {
+		t.Fatalf("SpectralRadius: got %v (%v)", radius, err)
+	}
+
+	// A repeated eigenvalue: 2 disconnected vertices each having Ti = 3^i
+	P, _ = CharPolyOf(Traces{6, 18}, 2)
+	if radius, err := P.SpectralRadius(); P.String() != "x^2 - 6x + 9" || err != nil || math.Abs(radius-3) > 1e-12 {
+		t.Fatalf("got %v, radius %v (%v)", P, radius, err)
+	}
+
+	// Repeated eigenvalues are as precise as simple ones: (x-2)^3 (x+1)^2 x
+	P = CharPoly{1, -4, 1, 10, -4, -8, 0}
+	spectrum, err = P.Spectrum()
+	if err != nil || len(spectrum) != 6 {
+		t.Fatalf("Spectrum: got %v (%v)", spectrum, err)
+	}
+	for i, want := range []float64{2, 2, 2, 0, -1, -1} {
+		if math.Abs(spectrum[i]-want) > 1e-12 {
+			t.Fatalf("Spectrum: got %v", spectrum)
+		}
+	}
+
+	// x^2 + 1 is not the characteristic polynomial of a symmetric matrix
+	if _, err := (CharPoly{1, 0, 1}).Spectrum(); !errors.Is(err, ErrInexactSpectrum) {
+		t.Fatalf("expected ErrInexactSpectrum, got %v", err)
+	}
+
+	if _, err := CharPolyOf(Traces{1, 2}, 2); err != ErrNotCharPoly {
+		t.Fatalf("expected ErrNotCharPoly, got %v", err)
+	}
+	if _, err := CharPolyOf(Traces{1}, 2); err != ErrInsufficientTraces {
+		t.Fatalf("expected ErrInsufficientTraces, got %v", err)
+	}
+	if same, err := IsCospectral(proton, Traces{5, 13, 35}); !same || err != nil {
+		t.Fatal("expected cospectral")
+	}
+}
+
+func TestTracesRecurrence(t *testing.T) {
+	// 1-2-3 has eigenvalues 3, 2, 0 so Tk = 3^k + 2^k
+	proton := Traces{5, 13, 35}
+	TX, err := ExtendedTraces(proton, 39)
+	if err != nil || len(TX) != 39 {
+		t.Fatalf("ExtendedTraces: got %v (%v)", TX, err)
+	}
+	pow3, pow2 := int64(1), int64(1)
+	for i, Ti := range TX {
+		pow3, pow2 = 3*pow3, 2*pow2
+		if Ti != pow3+pow2 {
+			t.Fatalf("T%d: got %d", i+1, Ti)
+		}
+	}
+	if _, err := ExtendedTraces(proton, 48); err != ErrTracesOverflow {
+		t.Fatalf("expected ErrTracesOverflow, got %v", err)
+	}
+
+	// the exact extension matches the direct computation
+	A := []int64{
+		0, 1, 0,
+		1, 0, 1,
+		0, 1, 2,
+	}
+	TXbig := BigTracesOf(A, 3, 64)
+	TX3, _ := TXbig[:3].Traces()
+	TXext, err := ExtendedBigTraces(TX3, 64)
+	if err != nil || TXext.String() != TXbig.String() {
+		t.Fatalf("ExtendedBigTraces:\n  %v\n  %v (%v)", TXext, TXbig, err)
+	}
+
+	// only the distinct non-zero eigenvalues (3 and 2) appear in the recurrence
+	P, err := FindRecurrence(TX[:6])
+	if err != nil || P.String() != "x^2 - 5x + 6" {
+		t.Fatalf("FindRecurrence: got %v (%v)", P, err)
+	}
+	if TXrec, err := P.ExtendTraces(TX[:2], 39); err != nil || !TXrec.IsEqual(TX) || len(TXrec) != 39 {
+		t.Fatalf("ExtendTraces: got %v (%v)", TXrec, err)
+	}
+
+	// a graph's characteristic polynomial is recovered once enough Traces are known
+	TX10, _ := TXbig[:10].Traces()
+	P, err = FindRecurrence(TX10)
+	if Pchar, _ := CharPolyOf(TX10, 3); err != nil || P.String() != Pchar.String() {
+		t.Fatalf("FindRecurrence: got %v, expected %v (%v)", P, Pchar, err)
+	}
+	if _, err := FindRecurrence(TX10[:3]); err != ErrInsufficientTraces {
+		t.Fatalf("expected ErrInsufficientTraces, got %v", err)
+	}
+}
+
+func TestPrimitiveTraces(t *testing.T) {
+	// 1-2-3 has eigenvalues 3, 2, 0 so Tk = 3^k + 2^k
+	TX, _ := CharPoly{1, -5, 6, 0}.Traces(12)
+	prim := TX.Primitive()
+	if prim[0] != TX[0] || prim[1] != TX[1]-TX[0] || prim[3] != TX[3]-TX[1] || prim[5] != TX[5]-TX[2]-TX[1]+TX[0] {
+		t.Fatalf("Primitive: got %v", prim)
+	}
+
+	// each primitive cycle of length k is counted once per rotation
+	for i, Pk := range prim {
+		if Pk%int64(i+1) != 0 {
+			t.Fatalf("P%d = %d is not divisible by %d", i+1, Pk, i+1)
+		}
+	}
+	if !prim.FromPrimitive().IsEqual(TX) {
+		t.Fatal("FromPrimitive failed")
+	}
+
+	for n, mu := range []int{0, 1, -1, -1, 0, -1, 1, -1, 0, 0, 1, -1, 0} {
+		if n > 0 && mobius(n) != mu {
+			t.Fatalf("mobius(%d): got %d", n, mobius(n))
+		}
+	}
+}
+
+func TestWalkMatrices(t *testing.T) {
+
+	// the path 1-2-3
+	A := []int64{
+		0, 1, 0,
+		1, 0, 1,
+		0, 1, 0,
+	}
+	Ms, err := WalkMatricesOf(A, 3, 0, []string{"a", "b", "c"})
+	if err != nil || len(Ms) != 3 {
+		t.Fatalf("expected 3 walk matrices (%v)", err)
+	}
+	if Ms[1].Walks[0][2] != 1 || Ms[1].Walks[1][1] != 2 || Ms[2].Trace() != 0 {
+		t.Fatalf("bad A^2 or A^3: %v", Ms)
+	}
+
+	// group the two ends together
+	G, err := Ms[1].Grouped([]int{0, 1, 0}, []string{"ends", "mid"})
+	if err != nil || G.Walks[0][0] != 4 || G.Walks[1][1] != 2 || G.Walks[0][1] != 0 {
+		t.Fatalf("bad grouped A^2: %v (%v)", G, err)
+	}
+
+	csv := strings.Builder{}
+	if err := WriteWalkMatricesCSV(&csv, []WalkMatrix{G}); err != nil {
+		t.Fatal(err)
+	}
+	if expect := "k,to\\from,ends,mid\n2,ends,4,0\n2,mid,0,2\n"; csv.String() != expect {
+		t.Fatalf("CSV: got %q, expected %q", csv.String(), expect)
+	}
+
+	js := strings.Builder{}
+	if err := WriteWalkMatricesJSON(&js, []WalkMatrix{G}); err != nil {
+		t.Fatal(err)
+	}
+	if expect := `[{"k":2,"labels":["ends","mid"],"walks":[[4,0],[0,2]]}]` + "\n"; js.String() != expect {
+		t.Fatalf("JSON: got %q, expected %q", js.String(), expect)
+	}
+
+	// walk counts are checked
+	if _, err := WalkMatricesOf([]int64{1 << 40}, 1, 2, []string{"x"}); err != ErrTracesOverflow {
+		t.Fatalf("expected overflow, got %v", err)
+	}
+}
@@ -2,10 +2,13 @@ package catalog
 
 import (
 	"bytes"
+	"context"
 	"runtime"
+	"sync"
 
 	"github.com/fine-structures/fine.SDK/go2x3"
 	"github.com/fine-structures/fine.SDK/lib2x3/factor"
+	"github.com/fine-structures/fine.SDK/lib2x3/graph"
 	lib2x3 "github.com/fine-structures/fine.SDK/lib2x3/graph-legacy"
 	"github.com/pkg/errors"
 
@@ -87,6 +90,7 @@ Next steps / ideas:
 
 var (
 	gCatalogStateKey = []byte{0x00, 0x00, 0x01}
+	gCatalogIndexKey = []byte{0x00, 0x00, 0x02} // => go2x3.TracesIndex (absent denotes go2x3.IndexByTraces)
 )
 
 // Catalog is a db wrapper for a 2x3 particle catalog
@@ -95,9 +99,11 @@ type catalog struct {
 	readOnly     bool
 	stateDirty   bool
 	state        go2x3.CatalogState
+	indexBy      go2x3.TracesIndex
 	db           *badger.DB
 	CatalogDesig string
 	primeCache   *factor.FactorCatalog
+	primeMu      sync.Mutex // guards loading primes into primeCache
 
 	// LSM double-lookup of a TracesID table:
 	//   TracesID <=> [1..TracesCount/2]varint64
@@ -154,6 +160,7 @@ func OpenCatalog(ctx go2x3.CatalogContext, opts go2x3.CatalogOpts) (go2x3.Catalo
 		cat.state.NumTraces = make([]uint64, opts.TraceCount+1)
 		cat.state.NumPrimes = make([]uint64, opts.TraceCount+1)
 		cat.state.TraceCount = opts.TraceCount
+		cat.indexBy = opts.IndexBy
 	}
 
 	if cat.state.MajorVers != 2022 || cat.state.MinorVers != 1 {
@@ -162,6 +169,8 @@ func OpenCatalog(ctx go2x3.CatalogContext, opts go2x3.CatalogOpts) (go2x3.Catalo
 		err = errors.New("Catalog's TraceCount is below the requested TraceCount")
 	} else if opts.NeedPrimes && !cat.state.IsPrimeCatalog {
 		err = errors.New("Catalog was not created to be a prime catalog")
+	} else if cat.indexBy != go2x3.IndexByTraces && cat.state.IsPrimeCatalog {
+		err = errors.New("A prime catalog must be indexed by Traces")
 	}
 
 	if err != nil {
@@ -198,6 +207,23 @@ func (cat *catalog) loadState() error {
 				return cat.state.Unmarshal(val)
 			})
 		}
+		if err != nil {
+			return err
+		}
+		item, err = txn.Get(gCatalogIndexKey)
+		if err == badger.ErrKeyNotFound {
+			cat.indexBy = go2x3.IndexByTraces
+			return nil
+		}
+		if err == nil {
+			err = item.Value(func(val []byte) error {
+				if len(val) != 1 {
+					return errors.Wrap(go2x3.ErrBadEncoding, "bad catalog index entry")
+				}
+				cat.indexBy = go2x3.TracesIndex(val[0])
+				return nil
+			})
+		}
 		return err
 	})
 	return err
@@ -214,6 +240,9 @@ func (cat *catalog) flushState() {
 			if err != nil {
 				return err
 			}
+			if cat.indexBy != go2x3.IndexByTraces {
+				err = txn.Set(gCatalogIndexKey, []byte{byte(cat.indexBy)})
+			}
 			return err
 		})
 		if err != nil {
@@ -274,54 +303,121 @@ func (cat *catalog) formCatalogKeyFromPrimeFactor(key []byte, factor go2x3.Trace
 	return key
 }
 
-func (cat *catalog) formTracesKey(key []byte, X go2x3.TracesProvider) []byte {
+// IndexBy returns which Traces this catalog indexes its graphs by.
+func (cat *catalog) IndexBy() go2x3.TracesIndex {
+	return cat.indexBy
+}
+
+// indexedTraces returns the first numTraces Traces this catalog indexes X by (see IndexBy).
+func (cat *catalog) indexedTraces(X go2x3.TracesProvider, numTraces int) (go2x3.Traces, error) {
+	if cat.indexBy == go2x3.IndexByNonBacktracking {
+		nb, ok := X.(go2x3.NonBacktrackingProvider)
+		if !ok {
+			return nil, errors.Wrap(go2x3.ErrNotSupported, "graph does not provide non-backtracking traces")
+		}
+		return nb.NonBacktrackingTraces(numTraces)
+	}
+	return go2x3.CheckedTraces(X, numTraces)
+}
+
+// formTracesKey appends the key of the Traces entry for X to the given buffer.
+//
+// Returns ErrInsufficientTraces if fewer than X.VertexCount() Traces are available, or the error encountered computing them
+// (e.g. ErrTracesOverflow, or ErrNotSupported if X can't provide the Traces this catalog is indexed by).
+func (cat *catalog) formTracesKey(key []byte, X go2x3.TracesProvider) ([]byte, error) {
 	Nv := X.VertexCount()
-	Nt := Nv // cat.TraceCount()
-	TX := X.Traces(Nt)
-	// if len(TX) == 0 || len(TX) < Nt {
-	//     return nil, go2x3.ErrInsufficientTraces
-	// }
+	TX, err := cat.indexedTraces(X, Nv)
+	if err != nil {
+		return nil, err
+	}
+	if len(TX) < Nv {
+		return nil, go2x3.ErrInsufficientTraces
+	}
 
 	key = append(key, byte(Nv))
 	key = TX.AppendTracesLSM(key)
 	key = append(key, 0, 0)
 
-	return key
+	return key, nil
 }
 
 // Select will call onHit() with all graphs matching the given search criteria.
 //
 // Warning: if onHit() retains the given GraphEncoding, then it must make a copy.
 //
-// Enumeration stops when there are no more matches or if onHit() returns false.
-func (cat *catalog) Select(sel go2x3.GraphSelector, onHit go2x3.OnStateHit) {
+// Enumeration stops when there are no more matches, once ctx is done, or if an entry fails to load (returning the error).
+func (cat *catalog) Select(ctx context.Context, sel go2x3.GraphSelector, onHit go2x3.OnStateHit) error {
+	if sel.SelectBosons && !cat.state.IsPrimeCatalog {
+		return errors.Wrap(go2x3.ErrNotSupported, "boson selection requires a prime catalog")
+	}
+
+	var err error
 	if sel.Traces != nil {
 		if sel.Factor {
-			cat.selectFactorizations(&sel, onHit)
+			err = cat.selectFactorizations(ctx, &sel, onHit)
 		} else {
-			cat.selectByTraces(&sel, onHit)
+			err = cat.selectByTraces(ctx, &sel, onHit)
 		}
 	} else {
-		cat.selectEncodings(&sel, onHit)
+		err = cat.selectEncodings(ctx, &sel, onHit)
+	}
+
+	// Cancellation is not a failure
+	if ctx.Err() != nil {
+		return nil
 	}
+	return err
 }
 
-func loadAndPushGraph(item *badger.Item, onHit go2x3.OnStateHit) error {
-	err := item.Value(func(val []byte) error {
-		X, err := lib2x3.NewGraphFromDef(val)
+// pushGraph sends X to onHit, reclaiming X and returning false if ctx is done first.
+func pushGraph(ctx context.Context, X go2x3.State, onHit go2x3.OnStateHit) bool {
+	select {
+	case onHit <- X:
+		return true
+	case <-ctx.Done():
+		X.Reclaim()
+		return false
+	}
+}
+
+// newStateFromDef loads a graph stored by TryAddGraph, which is either a legacy Graph or a graph.VtxState.
+func newStateFromDef(graphDef []byte) (go2x3.State, error) {
+	def := graph.GraphDef{}
+	if err := def.Unmarshal(graphDef); err != nil {
+		return nil, err
+	}
+	if graph.IsVtxStateEncoding(def.GraphEncoding) {
+		X := graph.NewVtxState(nil)
+		if err := X.InitFromEncoding(def.GraphEncoding); err != nil {
+			X.Reclaim()
+			return nil, err
+		}
+		return X, nil
+	}
+
+	X, err := lib2x3.NewGraphFromDef(graphDef)
+	if err != nil {
+		return nil, err
+	}
+	return X, nil
+}
+
+// loadAndPushGraph loads the graph stored in the given item and sends it to onHit.
+// Returns ctx.Err() if ctx is done first (in which case selection should stop).
+func loadAndPushGraph(ctx context.Context, item *badger.Item, onHit go2x3.OnStateHit) error {
+	return item.Value(func(val []byte) error {
+		X, err := newStateFromDef(val)
 		if err != nil {
-			return err
+			return errors.Wrapf(err, "failed to load catalog entry %x", item.Key())
+		}
+		if !pushGraph(ctx, X, onHit) {
+			return ctx.Err()
 		}
-		onHit <- X
 		return nil
 	})
-	if err != nil {
-		panic(err)
-	}
-	return err
 }
 
-func (cat *catalog) selectEncodings(sel *go2x3.GraphSelector, onHit go2x3.OnStateHit) {
+func (cat *catalog) selectEncodings(ctx context.Context, sel *go2x3.GraphSelector, onHit go2x3.OnStateHit) error {
 	minKey := [1]byte{sel.Min.NumVertex}
 
 	txn := cat.db.NewTransaction(false)
@@ -333,13 +429,7 @@ func (cat *catalog) selectEncodings(sel *go2x3.GraphSelector, onHit go2x3.OnStat
 	})
 	defer it.Close()
 
-	wantFlags := byte(0)
-	if sel.SelectPrimes {
-		wantFlags |= go2x3.Flag_IsPrime
-	}
-	if sel.SelectBosons {
-		wantFlags |= go2x3.Flag_IsBoson
-	}
+	wantFlags := sel.WantFlags()
 
 	var keyBuf [256]byte
 	tracesKey := append(keyBuf[:0], 0xFF, 0xFF) // suffix ensures no match
@@ -356,7 +446,9 @@ func (cat *catalog) selectEncodings(sel *go2x3.GraphSelector, onHit go2x3.OnStat
 		nextTraces := false
 
 		if bytes.HasPrefix(curKey, tracesKey) {
-			loadAndPushGraph(curItem, onHit)
+			if err := loadAndPushGraph(ctx, curItem, onHit); err != nil {
+				return err
+			}
 
 			if sel.UniqueTraces {
 				nextTraces = true
@@ -364,7 +456,7 @@ func (cat *catalog) selectEncodings(sel *go2x3.GraphSelector, onHit go2x3.OnStat
 		} else {
 			n := len(curKey)
 			if curKey[n-2] != 0 || curKey[n-1] != 0 { // check double NUL suffix
-				panic("what is this entry?")
+				return errors.Wrapf(go2x3.ErrBadEncoding, "unexpected catalog entry %x", curKey)
 			}
 
 			// A new prefix means a new Traces entry
@@ -373,6 +465,10 @@ func (cat *catalog) selectEncodings(sel *go2x3.GraphSelector, onHit go2x3.OnStat
 
 			if meta&wantFlags != wantFlags {
 				nextTraces = true
+			} else if selected, err := cat.selectsTraces(txn, sel, tracesKey); err != nil {
+				return err
+			} else if !selected {
+				nextTraces = true
 			}
 		}
 
@@ -385,14 +481,65 @@ func (cat *catalog) selectEncodings(sel *go2x3.GraphSelector, onHit go2x3.OnStat
 		}
 
 	}
+	return nil
+}
+
+// selectsTraces returns true if the Traces entry having the given header key meets sel.Predicates.
+//
+// The header only holds the first Nv Traces, so if the predicates need more, they are computed from the entry's first graph
+// (graphs sharing their first Nv Traces share all their Traces).
+func (cat *catalog) selectsTraces(txn *badger.Txn, sel *go2x3.GraphSelector, tracesKey []byte) (bool, error) {
+	if len(sel.Predicates) == 0 {
+		return true, nil
+	}
+
+	Nv := int(tracesKey[0])
+	numTraces := sel.PredicateTraces(Nv)
+
+	// A header only holds Traces when this catalog is indexed by them
+	if cat.indexBy == go2x3.IndexByTraces {
+		var TX go2x3.Traces
+		if err := TX.InitFromTracesLSM(tracesKey[1:], Nv); err != nil {
+			return false, errors.Wrapf(go2x3.ErrBadEncoding, "bad Traces entry %x", tracesKey)
+		}
+		if numTraces <= len(TX) {
+			return sel.SelectsTraces(TX), nil
+		}
+	}
+
+	it := txn.NewIterator(badger.IteratorOptions{
+		Prefix: tracesKey,
+	})
+	defer it.Close()
+
+	it.Rewind() // Traces header entry
+	if it.Valid() {
+		it.Next()
+	}
+	if !it.Valid() {
+		return false, nil
+	}
+
+	selected := false
+	err := it.Item().Value(func(val []byte) error {
+		X, err := newStateFromDef(val)
+		if err != nil {
+			return errors.Wrapf(err, "failed to load catalog entry %x", it.Item().Key())
+		}
+		selected = sel.SelectsTraces(X.Traces(numTraces))
+		X.Reclaim()
+		return nil
+	})
+	return selected, err
 }
 
 // Currently, the major downside with the current impl is that to read in all the primes requires a complete walk through the TracesCatalog.
 func (cat *catalog) readPrimes(
+	ctx context.Context,
 	txn *badger.Txn,
 	Nv byte,
 	onHit go2x3.OnStateHit,
-) {
+) error {
 	minKey := [1]byte{Nv}
 	it := txn.NewIterator(badger.IteratorOptions{
 		PrefetchValues: false,
@@ -416,14 +563,17 @@ func (cat *catalog) readPrimes(
 				// Use the first entry after the Traces entry as the prime's encoding
 				// We could also load primes directly from a primes table (also allowing us to easily store the "common" encoding of a prime)
 				it.Next()
-				loadAndPushGraph(it.Item(), onHit)
+				if err := loadAndPushGraph(ctx, it.Item(), onHit); err != nil {
+					return err
+				}
 			}
 
 			it.Seek(resumeAt)
 		} else {
-			panic("expected Traces entry")
+			return errors.Wrapf(go2x3.ErrBadEncoding, "expected Traces entry, got %x", curKey)
 		}
 	}
+	return nil
 }
 
 /*
@@ -477,13 +627,16 @@ func (cat *catalog) lookupTracesID(txn *badger.Txn, X *lib2x3.Graph, autoAdd boo
 }
 */
 
-func (cat *catalog) selectByTraces(sel *go2x3.GraphSelector, onHit go2x3.OnStateHit) {
+func (cat *catalog) selectByTraces(ctx context.Context, sel *go2x3.GraphSelector, onHit go2x3.OnStateHit) error {
 	if sel.Traces == nil {
-		return
+		return nil
 	}
 
 	var keyBuf [256]byte
-	tracesKey := cat.formTracesKey(keyBuf[:0], sel.Traces)
+	tracesKey, err := cat.formTracesKey(keyBuf[:0], sel.Traces)
+	if err != nil {
+		return err
+	}
 
 	txn := cat.db.NewTransaction(false)
 	defer txn.Discard()
@@ -498,7 +651,7 @@ func (cat *catalog) selectByTraces(sel *go2x3.GraphSelector, onHit go2x3.OnState
 	// First item should be the Traces entry header entry.  If not present, then there are no particles with a matching Traces.
 	it.Rewind()
 	if !it.Valid() {
-		return
+		return nil
 	}
 
 	// Diagnostic -- the first key we match should be the Traces only key
@@ -507,7 +660,16 @@ func (cat *catalog) selectByTraces(sel *go2x3.GraphSelector, onHit go2x3.OnState
 
 		klen := len(curKey)
 		if curKey[klen-2] != 0 || curKey[klen-1] != 0 { // check double NUL suffix
-			panic("expected Traces header entry")
+			return errors.Wrapf(go2x3.ErrBadEncoding, "expected Traces header entry, got %x", curKey)
+		}
+
+		// Apply the same Traces-level criteria as selectEncodings
+		wantFlags := sel.WantFlags()
+		if it.Item().UserMeta()&wantFlags != wantFlags {
+			return nil
+		}
+		if selected, err := cat.selectsTraces(txn, sel, curKey); err != nil || !selected {
+			return err
 		}
 	}
 
@@ -531,8 +693,14 @@ func (cat *catalog) selectByTraces(sel *go2x3.GraphSelector, onHit go2x3.OnState
 				panic("end of traces key not found")
 			} */
 
-		loadAndPushGraph(it.Item(), onHit)
+		if err := loadAndPushGraph(ctx, it.Item(), onHit); err != nil {
+			return err
+		}
+		if sel.UniqueTraces {
+			break
+		}
 	}
+	return nil
 }
 
 /*
@@ -689,10 +857,14 @@ func (cat *catalog) lookupGraph(X *lib2x3.Graph) (isNewTraces, isNewGraph bool)
 // If true is returned, X was not present and was added.
 //
 // If false is returned, X already exists in the particle registry (or the graph is not valid
+// or its indexed Traces can't be computed, such as if they overflow -- see formTracesKey).
 func (cat *catalog) TryAddGraph(X go2x3.State) bool {
 	var keyBuf, valBuf [256]byte
 
-	lsmTraces := cat.formTracesKey(keyBuf[:0], X)
+	lsmTraces, err := cat.formTracesKey(keyBuf[:0], X)
+	if err != nil {
+		return false
+	}
 	lsmState, err := X.MarshalOut(lsmTraces, go2x3.AsState)
 	if err != nil {
 		return false
@@ -724,25 +896,17 @@ func (cat *catalog) TryAddGraph(X go2x3.State) bool {
 
 	flags := byte(0)
 
-	// If this Traces hasn't been prime tested before and this is a prime catalog, then do so now.
+	// Traces flags are only maintained by prime catalogs (IsPrimeCatalog is persisted), so catalogs that predate
+	// Flag_IsBoson or that were opened without NeedPrimes never report partial boson selections (see Select).
 	if isNewTraces && cat.state.IsPrimeCatalog {
-
-		// prime testing requires primes up to Nv-1
+		if X.Traces(0).IsBoson() {
+			flags |= go2x3.Flag_IsBoson
+		}
 		Nv := X.VertexCount()
-		cat.cachePrimesAsNeeded(int(Nv - 1))
-
-		TX := X.Traces(0)
-		if cat.primeCache.IsPrime(TX) {
+		if cat.IsPrime(X.Traces(0)) {
 			flags |= go2x3.Flag_IsPrime
 			cat.issueNextPrimeID(Nv)
 		}
-		bosonFlag := go2x3.Flag_IsBoson
-		for i, TXi := range TX {
-			if i&1 == 0 && TXi != 0 { // if any odd traces are none-zero, then not a boson
-				bosonFlag = 0
-			}
-		}
-		flags |= bosonFlag
 	}
 
 	// Write the new entries
@@ -770,14 +934,19 @@ func (cat *catalog) TryAddGraph(X go2x3.State) bool {
 }
 
 // TODO: move to factor.go, i.e. factorCatalog.SelectFactorizations(cat, sel, onHit)
-func (cat *catalog) selectFactorizations(sel *go2x3.GraphSelector, onHit go2x3.OnStateHit) {
+func (cat *catalog) selectFactorizations(ctx context.Context, sel *go2x3.GraphSelector, onHit go2x3.OnStateHit) error {
 	if sel.Traces == nil {
-		return
+		return nil
 	}
 
 	TX := sel.Traces.Traces(0)
 	Nv := len(TX)
-	cat.cachePrimesAsNeeded(Nv)
+	if len(sel.Predicates) > 0 && !sel.SelectsTraces(sel.Traces.Traces(sel.PredicateTraces(Nv))) {
+		return nil // every factorization has the same Traces
+	}
+	if err := cat.cachePrimesAsNeeded(Nv); err != nil {
+		return err
+	}
 
 	factorSetsIn := cat.primeCache.FindFactorizations(TX)
 
@@ -789,10 +958,19 @@ func (cat *catalog) selectFactorizations(sel *go2x3.GraphSelector, onHit go2x3.O
 		seeker := newEasySeeker(txnRO)
 		defer seeker.Close()
 
+		// Once stopped, remaining factorizations are drained (so the search can complete) but not formed.
+		var err error
 		for factorSet := range factorSetsIn {
-			X := cat.formGraphFromFactors(seeker, factorSet)
-			onHit <- X
+			if err != nil || ctx.Err() != nil {
+				continue
+			}
+			var X *lib2x3.Graph
+			X, err = cat.formGraphFromFactors(seeker, factorSet)
+			if err == nil {
+				pushGraph(ctx, X, onHit)
+			}
 		}
+		return err
 	}
 }
 
@@ -928,11 +1106,27 @@ func (cat *catalog) factorsForTID(
 }
 */
 
+// IsPrime returns true if the given Traces (of a graph with len(TX) vertices) has no factorization into primes in this catalog.
+// This allows a prime catalog to be used as a go2x3.PrimeTester (e.g. for GraphSelector.Primes).
+//
+// Returns false if this is not a prime catalog.
+func (cat *catalog) IsPrime(TX go2x3.Traces) bool {
+
+	// prime testing requires primes up to Nv-1
+	if err := cat.cachePrimesAsNeeded(len(TX) - 1); err != nil {
+		return false
+	}
+	return cat.primeCache.IsPrime(TX)
+}
+
 func (cat *catalog) cachePrimesAsNeeded(Nv int) error {
 	if cat.primeCache == nil {
 		return errors.New("not a prime catalog, son")
 	}
 
+	cat.primeMu.Lock()
+	defer cat.primeMu.Unlock()
+
 	have_vi := cat.primeCache.HasFactorsUpTo()
 	if have_vi >= Nv {
 		return nil
@@ -949,8 +1143,9 @@ func (cat *catalog) cachePrimesAsNeeded(Nv int) error {
 		// Used a buffered channel so that db I/O blocks don't stall Traces computation
 		onPrime := make(chan go2x3.State, 4)
 
+		var readErr error
 		go func() {
-			cat.readPrimes(txnRO, byte(vi), onPrime)
+			readErr = cat.readPrimes(context.Background(), txnRO, byte(vi), onPrime)
 			close(onPrime)
 		}()
 
@@ -959,6 +1154,9 @@ func (cat *catalog) cachePrimesAsNeeded(Nv int) error {
 			cat.primeCache.AddCopy(byte(vi), TX)
 			Xpr.Reclaim()
 		}
+		if readErr != nil {
+			return readErr
+		}
 	}
 
 	return nil
@@ -1020,10 +1218,11 @@ func (cat *catalog) readPrimes(
 func (cat *catalog) formGraphFromFactors(
 	seeker easySeeker,
 	primeFactors go2x3.FactorSet,
-) *lib2x3.Graph {
+) (*lib2x3.Graph, error) {
 
 	X := lib2x3.NewGraph(nil)
 	Xi := lib2x3.NewGraph(nil)
+	defer Xi.Reclaim()
 
 	var keyBuf [256]byte
 	for _, Pi := range primeFactors {
@@ -1032,17 +1231,16 @@ func (cat *catalog) formGraphFromFactors(
 			err := Xi.InitFromDef(val)
 			return err
 		})
-		if err == nil {
-			for fi := uint32(0); fi < Pi.Count; fi++ {
-				X.Concatenate(Xi)
-			}
-		} else {
-			panic(err)
+		if err != nil {
+			X.Reclaim()
+			return nil, errors.Wrapf(err, "failed to load prime factor %v", Pi.ID)
+		}
+		for fi := uint32(0); fi < Pi.Count; fi++ {
+			X.Concatenate(Xi)
 		}
 	}
 
-	Xi.Reclaim()
-	return X
+	return X, nil
 }
 
 /*
@@ -1,6 +1,8 @@
 package catalog_test
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path"
@@ -9,6 +11,7 @@ import (
 
 	"github.com/fine-structures/fine.SDK/go2x3"
 	"github.com/fine-structures/fine.SDK/lib2x3/catalog"
+	"github.com/fine-structures/fine.SDK/lib2x3/graph"
 	lib2x3 "github.com/fine-structures/fine.SDK/lib2x3/graph-legacy"
 )
 
@@ -82,7 +85,7 @@ func TestBasics(t *testing.T) {
 		total := 0
 		onHit := make(chan go2x3.State)
 		go func() {
-			cat.Select(go2x3.DefaultGraphSelector, onHit)
+			cat.Select(context.Background(), go2x3.DefaultGraphSelector, onHit)
 			close(onHit)
 		}()
 		for X := range onHit {
@@ -94,6 +97,137 @@ func TestBasics(t *testing.T) {
 		}
 	}
 
+	// SelectAll -- same as above but as an iterator, also checking an early break
+	{
+		ctx := context.Background()
+		total := 0
+		for X, err := range go2x3.SelectAll(ctx, cat, go2x3.DefaultGraphSelector) {
+			if err != nil {
+				t.Fatal(err)
+			}
+			if X.VertexCount() == 0 {
+				t.Fatal("bad graph")
+			}
+			total++
+		}
+		if total != 9 {
+			t.Fatal("SelectAll fail")
+		}
+
+		total = 0
+		for range go2x3.SelectAll(ctx, cat, go2x3.DefaultGraphSelector) {
+			total++
+			if total == 3 {
+				break
+			}
+		}
+		if total != 3 {
+			t.Fatal("SelectAll break fail")
+		}
+	}
+
+	// A selector should select the same graphs from a catalog as from a stream
+	{
+		ctx := context.Background()
+		primeTester, ok := cat.(go2x3.PrimeTester)
+		if !ok {
+			t.Fatal("prime catalog should be a PrimeTester")
+		}
+
+		for _, mode := range []string{"primes", "bosons", "unique"} {
+			sel := go2x3.DefaultGraphSelector
+			switch mode {
+			case "primes":
+				sel.SelectPrimes = true
+			case "bosons":
+				sel.SelectBosons = true
+			case "unique":
+				sel.UniqueTraces = true
+			}
+
+			fromCat := go2x3.SelectFromCatalog(ctx, cat, sel).PullAll()
+
+			sel.Primes = primeTester
+			fromStream := go2x3.SelectFromCatalog(ctx, cat, go2x3.DefaultGraphSelector).
+				SelectFromStream(ctx, sel, go2x3.StageOpts{Workers: 2})
+			if count := fromStream.PullAll(); count != fromCat || fromStream.Err() != nil {
+				t.Fatalf("%s: catalog selected %d, stream selected %d (err: %v)", mode, fromCat, count, fromStream.Err())
+			}
+			if fromCat == 0 {
+				t.Fatalf("%s: expected a selection, got none", mode)
+			}
+		}
+
+		for _, query := range []string{
+			"v in 1..2 && prime && T1 < 0",
+			"odd == 0 && T2 % 2 == 0",
+			"!boson && T3 != 0 && v <= 3",
+			"v <= 2 && T4 > 0",
+		} {
+			sel, err := go2x3.ParseQuery(query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fromCat := go2x3.SelectFromCatalog(ctx, cat, sel).PullAll()
+			sel.Primes = primeTester
+			fromStream := go2x3.SelectFromCatalog(ctx, cat, go2x3.DefaultGraphSelector).SelectFromStream(ctx, sel, go2x3.StageOpts{})
+			if count := fromStream.PullAll(); count != fromCat || fromCat == 0 {
+				t.Fatalf("%q: catalog selected %d, stream selected %d", query, fromCat, count)
+			}
+			if count := go2x3.SelectQuery(ctx, cat, query).PullAll(); count != fromCat {
+				t.Fatalf("%q: SelectQuery selected %d, expected %d", query, count, fromCat)
+			}
+		}
+
+		// SelectQuery on a stream, and bad queries failing either stream
+		{
+			query := "odd == 0 && T2 % 2 == 0"
+			fromCat := go2x3.SelectQuery(ctx, cat, query).PullAll()
+			fromStream := go2x3.SelectFromCatalog(ctx, cat, go2x3.DefaultGraphSelector).SelectQuery(ctx, query, go2x3.StageOpts{})
+			if count := fromStream.PullAll(); count != fromCat || fromStream.Err() != nil {
+				t.Fatalf("%q: catalog selected %d, stream selected %d (err: %v)", query, fromCat, count, fromStream.Err())
+			}
+
+			for _, bad := range []*go2x3.GraphStream{
+				go2x3.SelectQuery(ctx, cat, "v >"),
+				go2x3.SelectFromCatalog(ctx, cat, go2x3.DefaultGraphSelector).SelectQuery(ctx, "v >", go2x3.StageOpts{}),
+			} {
+				if count := bad.PullAll(); count != 0 || !errors.Is(bad.Err(), go2x3.ErrBadQuery) {
+					t.Fatalf("bad query: expected ErrBadQuery, got %d graphs, err %v", count, bad.Err())
+				}
+			}
+		}
+
+		// UniqueTraces selects the same graphs however many workers are used
+		uniques := func(opts go2x3.StageOpts) (out []string) {
+			sel := go2x3.DefaultGraphSelector
+			sel.UniqueTraces = true
+			for X := range go2x3.SelectFromCatalog(ctx, cat, go2x3.DefaultGraphSelector).SelectFromStream(ctx, sel, opts).All() {
+				enc, _ := X.MarshalOut(nil, go2x3.AsAscii)
+				out = append(out, string(enc))
+			}
+			return out
+		}
+		serial := uniques(go2x3.StageOpts{})
+		if len(serial) == 0 || serial[0] == "" {
+			t.Fatal("expected a unique selection")
+		}
+		if strings.Join(serial, "|") != strings.Join(uniques(go2x3.StageOpts{Workers: 4, Unordered: true}), "|") {
+			t.Fatal("unique selection differs across workers")
+		}
+
+		// an invalid selector fails immediately
+		sel := go2x3.DefaultGraphSelector
+		sel.SelectPrimes = true
+		stream := go2x3.SelectFromCatalog(ctx, cat, go2x3.DefaultGraphSelector).SelectFromStream(ctx, sel, go2x3.StageOpts{})
+		if !errors.Is(stream.Err(), go2x3.ErrNoPrimeTester) {
+			t.Fatal("expected ErrNoPrimeTester")
+		}
+		if count := stream.PullAll(); count != 0 {
+			t.Fatalf("expected no graphs from a failed stream, got %d", count)
+		}
+	}
+
 	// Factor a photon -- should get e + ~e
 	{
 		Xsrc := lib2x3.NewGraph(nil)
@@ -106,7 +240,7 @@ func TestBasics(t *testing.T) {
 		total := 0
 		onHit := make(chan go2x3.State)
 		go func() {
-			cat.Select(sel, onHit)
+			cat.Select(context.Background(), sel, onHit)
 			close(onHit)
 		}()
 		for X := range onHit {
@@ -129,3 +263,143 @@ func PrintGraph(prefix string, X go2x3.State) {
 	X.WriteCSV(&b, go2x3.PrintOpts{})
 	fmt.Println(b.String())
 }
+
+func TestIndexByNonBacktracking(t *testing.T) {
+	dir, err := os.MkdirTemp("", "junk*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if _, err := catalog.OpenCatalog(gCtx, go2x3.CatalogOpts{NeedPrimes: true, IndexBy: go2x3.IndexByNonBacktracking}); err == nil {
+		t.Fatal("expected a prime catalog to require IndexByTraces")
+	}
+
+	opts := go2x3.CatalogOpts{
+		DbPathName: path.Join(dir, "TestIndexByNonBacktracking"),
+		IndexBy:    go2x3.IndexByNonBacktracking,
+	}
+	cat, err := catalog.OpenCatalog(gCtx, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	graphs := []string{"1-2-3-4", "1-2,1-3,1-4", "1-2-3-1,3-4", "1-2-3-1,1-4,2-4,3-4"}
+	X := lib2x3.NewGraph(nil)
+	for _, Xstr := range graphs {
+		X.InitFromString(Xstr)
+		if !cat.TryAddGraph(X) {
+			t.Fatalf("%s: not added", Xstr)
+		}
+		if cat.TryAddGraph(X) {
+			t.Fatalf("%s: added twice", Xstr)
+		}
+	}
+	cat.Close()
+
+	// Reopening uses the index the catalog was created with
+	opts.IndexBy = go2x3.IndexByTraces
+	cat, err = catalog.OpenCatalog(gCtx, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cat.Close()
+
+	ctx := context.Background()
+	query, err := go2x3.ParseQuery("T3 > 30")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectQuery := 0
+	for _, Xstr := range graphs {
+		X.InitFromString(Xstr)
+		if query.SelectsTraces(X.Traces(query.PredicateTraces(X.VertexCount()))) {
+			expectQuery++
+		}
+
+		sel := go2x3.DefaultGraphSelector
+		sel.Traces = X
+		if count := go2x3.SelectFromCatalog(ctx, cat, sel).PullAll(); count != 1 {
+			t.Fatalf("%s: selected %d graphs", Xstr, count)
+		}
+
+		// plain Traces are not what this catalog is indexed by
+		sel.Traces = append(go2x3.Traces{}, X.Traces(0)...)
+		byTraces := go2x3.SelectFromCatalog(ctx, cat, sel)
+		if count := byTraces.PullAll(); count != 0 || !errors.Is(byTraces.Err(), go2x3.ErrNotSupported) {
+			t.Fatalf("%s: selected %d graphs by Traces (%v)", Xstr, count, byTraces.Err())
+		}
+	}
+
+	// Predicates on (plain) Traces still apply
+	if count := go2x3.SelectFromCatalog(ctx, cat, query).PullAll(); count != expectQuery || count == 0 || count == len(graphs) {
+		t.Fatalf("query selected %d, expected %d", count, expectQuery)
+	}
+
+	// Only prime catalogs flag bosons
+	sel := go2x3.DefaultGraphSelector
+	sel.SelectBosons = true
+	stream := go2x3.SelectFromCatalog(ctx, cat, sel)
+	if count := stream.PullAll(); count != 0 || !errors.Is(stream.Err(), go2x3.ErrNotSupported) {
+		t.Fatalf("boson selection: expected ErrNotSupported, got %d graphs, err %v", count, stream.Err())
+	}
+}
+
+func TestVtxStates(t *testing.T) {
+	dir, err := os.MkdirTemp("", "junk*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cat, err := catalog.OpenCatalog(gCtx, go2x3.CatalogOpts{
+		DbPathName: path.Join(dir, "TestVtxStates"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cat.Close()
+
+	newState := func(edges [][3]int64) *graph.VtxState {
+		X := graph.NewVtxState(nil)
+		for _, e := range edges {
+			X.AddEdge(e[2], uint32(e[0]), uint32(e[1]))
+		}
+		return X
+	}
+
+	// weighted graphs (not expressible as 2x3 graphs), where the second is the first relabeled
+	graphs := []*graph.VtxState{
+		newState([][3]int64{{1, 1, 2}, {1, 2, 2}, {2, 3, -1}}),
+		newState([][3]int64{{3, 3, 2}, {2, 3, 2}, {1, 2, -1}}),
+		newState([][3]int64{{1, 2, 2}, {2, 3, 2}, {1, 3, 2}}),
+	}
+	for i, X := range graphs {
+		if added := cat.TryAddGraph(X); added != (i != 1) {
+			t.Fatalf("graph %d: added %v", i, added)
+		}
+	}
+
+	// a graph whose Traces overflow can't be indexed
+	if cat.TryAddGraph(newState([][3]int64{{1, 2, 2e9}, {2, 3, 2e9}, {1, 3, 2e9}})) {
+		t.Fatal("added a graph whose Traces overflow")
+	}
+
+	// graphs are loaded back as VtxStates
+	ctx := context.Background()
+	for _, i := range []int{0, 2} {
+		sel := go2x3.DefaultGraphSelector
+		sel.Traces = graphs[i]
+		count := 0
+		stream := go2x3.SelectFromCatalog(ctx, cat, sel)
+		for X := range stream.All() {
+			if _, ok := X.(*graph.VtxState); !ok || !X.Traces(0).IsEqual(graphs[i].Traces(0)) {
+				t.Fatalf("graph %d: loaded %T with Traces %v", i, X, X.Traces(0))
+			}
+			count++
+		}
+		if count != 1 || stream.Err() != nil {
+			t.Fatalf("graph %d: selected %d (%v)", i, count, stream.Err())
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package lib2x3
+
+import (
+	"github.com/fine-structures/fine.SDK/go2x3"
+	"github.com/fine-structures/fine.SDK/lib2x3/graph"
+	walker "github.com/fine-structures/fine.SDK/lib2x3/graph-walker"
+)
+
+func init() {
+	graph.RegisterCodec(graphExprCodec{})
+}
+
+// graphExprCodec encodes a graph as its graph expression and decodes either a graph expression or an op expression.
+type graphExprCodec struct{}
+
+func (graphExprCodec) EncodingForm() graph.EncodingForm {
+	return graph.EncodingForm_GraphExpr
+}
+
+func (graphExprCodec) AppendEncoding(out []byte, X go2x3.State) ([]byte, error) {
+	Xg, ok := X.(*Graph)
+	if !ok {
+		Xg = NewGraph(nil)
+		defer Xg.Reclaim()
+		if err := Xg.InitFromState(X); err != nil {
+			return out, err
+		}
+	}
+
+	// AppendGraphExpr separates edge runs with spaces, so join them with commas to be read back as a single part.
+	start := len(out)
+	out = Xg.AppendGraphExpr(out)
+	for i := start; i < len(out); i++ {
+		if out[i] == ' ' {
+			out[i] = ','
+		}
+	}
+	return out, nil
+}
+
+func (graphExprCodec) Decode(enc []byte) (go2x3.State, error) {
+	X := NewGraph(nil)
+	if err := X.initFromExprText(string(enc)); err != nil {
+		X.Reclaim()
+		return nil, err
+	}
+	return X, nil
+}
+
+// InitFromState assigns this Graph from the given State, returning go2x3.ErrNotSupported if it is not a 2x3 graph.
+func (X *Graph) InitFromState(Xsrc go2x3.State) error {
+	switch Xsrc := Xsrc.(type) {
+	case *Graph:
+		X.Init(Xsrc)
+		return nil
+	case *walker.Construction:
+		X.Init(nil)
+		g := &opGraph{}
+		for _, v := range Xsrc.Vtx {
+			negLoops := byte(0)
+			for _, e := range v.Edges {
+				switch {
+				case e.To == 0 && e.Sign < 0:
+					negLoops++
+				case e.To > v.ID: // each edge appears on both of its vertices
+					g.edges = append(g.edges, opEdge{va: VtxID(v.ID), vb: VtxID(e.To), neg: e.Sign < 0})
+				}
+			}
+			g.negLoops = append(g.negLoops, negLoops)
+		}
+		return g.exportTo(X)
+	}
+	return go2x3.ErrNotSupported
+}
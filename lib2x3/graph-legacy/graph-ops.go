@@ -0,0 +1,350 @@
+package lib2x3
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/fine-structures/fine.SDK/go2x3"
+	"github.com/fine-structures/fine.SDK/lib2x3/graph"
+)
+
+// An OpNode tree composes a graph from smaller ones (see graph.OpCode), where each node first sums its components
+// (and its GraphExpr, if set) and then applies its op:
+//
+//	Sum       disjoint union of the components
+//	Power     Repeat disjoint copies; if VtxID is set, the copies are joined into a ring, where vertex VtxID of each copy
+//	          is joined to vertex 1 of the next copy (e.g. Power(4, VtxID=2) of "1=2" is y8, "1=2-3=4-5=6-7=8-1")
+//	Mirror    a duplicate is added, and each open slot of each vertex is joined to its corresponding mirror vertex
+//	Replace   vertex VtxID becomes a ring of 3 vertices, each taking one of the replaced vertex's edges or loops
+//
+// Open slots are those not taken by an edge or negative loop (i.e. positive loops), so a vertex's open slots are consumed
+// as edges are joined to it.  A GraphExpr may also name a well known graph (see NamedGraphs).
+
+// NamedGraphs maps each graph name usable in an op expression (e.g. "sum(p+, e-)") to its graph expression.
+// Names are added via RegisterNamedGraph, which rejects names reserved for ops (see graph.CheckGraphName).
+var NamedGraphs = map[string]string{
+	"e-":    "1",
+	"e+":    "1^^^",
+	"μ-":    "1-2=3",
+	"τ-":    "1-2=3-4=5",
+	"p+":    "1-2-3",
+	"n0":    "1-2-3-1-4",
+	"W-":    "1^^",
+	"gamma": "1---2",
+	"tetra": "1-2-3-1-4-2,3-4",
+	"higgs": "1-2-3-4-1-5-6-7-8-5,2-6,3-7,4-8",
+}
+
+// RegisterNamedGraph adds (or replaces) a graph name usable in an op expression, returning an error if name is not a
+// valid graph name (see graph.CheckGraphName) or graphExpr is not a valid graph expression.
+func RegisterNamedGraph(name, graphExpr string) error {
+	if err := graph.CheckGraphName(name); err != nil {
+		return err
+	}
+	X := NewGraph(nil)
+	defer X.Reclaim()
+	if err := X.InitFromString(graphExpr); err != nil {
+		return err
+	}
+	NamedGraphs[name] = graphExpr
+	return nil
+}
+
+// opGraph is an intermediate graph used to evaluate an OpNode tree, where each edge is a unit (single) edge.
+type opGraph struct {
+	negLoops []byte // negative loops of each vertex
+	edges    []opEdge
+}
+
+// opEdge is a unit edge connecting two distinct vertices.
+type opEdge struct {
+	va, vb VtxID
+	neg    bool
+}
+
+// InitFromExpr assigns this Graph from a graph expression (e.g. "1-2-3,1-4"), an op expression (e.g. "mirror(1-2-3)"),
+// or a graph in any other registered EncodingForm (see graph.SniffEncodingForm).
+func (X *Graph) InitFromExpr(expr string) error {
+	if form := graph.SniffEncodingForm([]byte(expr)); form != graph.EncodingForm_GraphExpr {
+		Xsrc, err := graph.DecodeGraph([]byte(expr), form)
+		if err != nil {
+			X.Init(nil)
+			return err
+		}
+		defer Xsrc.Reclaim()
+		return X.InitFromState(Xsrc)
+	}
+	return X.initFromExprText(expr)
+}
+
+// initFromExprText assigns this Graph from either a graph expression or an op expression.
+// Since op and graph names can't begin with a digit (see graph.CheckGraphName), a leading digit denotes a graph expression.
+func (X *Graph) initFromExprText(expr string) error {
+	if trimmed := strings.TrimSpace(expr); trimmed == "" || (trimmed[0] >= '0' && trimmed[0] <= '9') {
+		return X.InitFromString(expr)
+	}
+	return X.InitFromOpExpr(expr)
+}
+
+// InitFromOpExpr assigns this Graph to the graph that the given op expression (see graph.ParseOpExpr) evaluates to.
+func (X *Graph) InitFromOpExpr(opExpr string) error {
+	op, err := graph.ParseOpExpr(opExpr)
+	if err != nil {
+		X.Init(nil)
+		return err
+	}
+	return X.InitFromOpNode(op)
+}
+
+// InitFromOpNode assigns this Graph to the graph that the given OpNode tree evaluates to, adding the tree's op expression
+// to X.Def.GraphExprs.
+func (X *Graph) InitFromOpNode(op *graph.OpNode) error {
+	X.Init(nil)
+
+	g, err := evalOpNode(op)
+	if err != nil {
+		return err
+	}
+	if err = g.exportTo(X); err != nil {
+		return err
+	}
+	X.Def.TryAddGraphExpr(string(op.AppendOpExpr(nil)))
+	return nil
+}
+
+func evalOpNode(op *graph.OpNode) (*opGraph, error) {
+	if op == nil {
+		return nil, go2x3.ErrBadOpNode
+	}
+
+	g := &opGraph{}
+	if op.GraphExpr != "" {
+		expr := op.GraphExpr
+		if named, ok := NamedGraphs[expr]; ok {
+			expr = named
+		}
+		Xexpr := NewGraph(nil)
+		err := Xexpr.InitFromString(expr)
+		if err == nil {
+			g.addGraph(Xexpr)
+		}
+		Xexpr.Reclaim()
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, sub := range op.Components {
+		gi, err := evalOpNode(sub)
+		if err != nil {
+			return nil, err
+		}
+		g.add(gi)
+	}
+	if g.numVtx() == 0 {
+		return nil, go2x3.ErrBadOpNode
+	}
+
+	switch op.OpCode {
+	case graph.OpCode_Nil, graph.OpCode_Sum:
+	case graph.OpCode_Power:
+		var err error
+		if g, err = g.power(op.Repeat, op.VtxID); err != nil {
+			return nil, err
+		}
+	case graph.OpCode_Mirror:
+		g.mirror()
+	case graph.OpCode_Replace:
+		if err := g.replace(op.VtxID); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, go2x3.ErrBadOpNode
+	}
+
+	if err := g.validate(); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// validate checks that this opGraph is a valid 2x3 graph (as each op assumes).
+func (g *opGraph) validate() error {
+	Nv := g.numVtx()
+	if Nv > MaxVtxID {
+		return go2x3.ErrBadVtxID
+	}
+	for v := VtxID(1); v <= VtxID(Nv); v++ {
+		if g.openSlots(v) < 0 {
+			return go2x3.ErrSitesExceeded
+		}
+	}
+	return nil
+}
+
+func (g *opGraph) numVtx() int {
+	return len(g.negLoops)
+}
+
+// openSlots returns the number of slots of the given vertex not taken by an edge or negative loop.
+func (g *opGraph) openSlots(v VtxID) int {
+	open := 3 - int(g.negLoops[v-1])
+	for _, e := range g.edges {
+		if e.va == v || e.vb == v {
+			open--
+		}
+	}
+	return open
+}
+
+// add appends a copy of src, offsetting its VtxIDs to follow the existing vertices.
+func (g *opGraph) add(src *opGraph) {
+	v0 := VtxID(g.numVtx())
+	g.negLoops = append(g.negLoops, src.negLoops...)
+	for _, e := range src.edges {
+		g.edges = append(g.edges, opEdge{e.va + v0, e.vb + v0, e.neg})
+	}
+}
+
+func (g *opGraph) addGraph(X *Graph) {
+	v0 := VtxID(g.numVtx())
+	for _, v := range X.Vtx() {
+		g.negLoops = append(g.negLoops, v.NegLoops())
+	}
+	for _, edge := range X.Edges() {
+		a, b := edge.VtxAB()
+		numPos, numNeg := edge.EdgeType().NumPosNeg()
+		for i := byte(0); i < numPos+numNeg; i++ {
+			g.edges = append(g.edges, opEdge{a + v0, b + v0, i >= numPos})
+		}
+	}
+}
+
+func (g *opGraph) power(repeat, vtxID int64) (*opGraph, error) {
+	Nv := g.numVtx()
+	if repeat < 1 {
+		return nil, go2x3.ErrBadOpNode
+	}
+	if vtxID < 0 || int(vtxID) > Nv {
+		return nil, go2x3.ErrBadVtxID
+	}
+	if repeat > int64(MaxVtxID/Nv) {
+		return nil, go2x3.ErrBadVtxID
+	}
+
+	out := &opGraph{}
+	for i := int64(0); i < repeat; i++ {
+		out.add(g)
+	}
+	if vtxID > 0 && repeat > 1 {
+		for i := 0; i < int(repeat); i++ {
+			next := (i + 1) % int(repeat)
+			out.edges = append(out.edges, opEdge{
+				va: VtxID(i*Nv) + VtxID(vtxID),
+				vb: VtxID(next*Nv) + 1,
+			})
+		}
+	}
+	return out, nil
+}
+
+func (g *opGraph) mirror() {
+	Nv := VtxID(g.numVtx())
+	open := make([]int, Nv)
+	for v := VtxID(1); v <= Nv; v++ {
+		open[v-1] = g.openSlots(v)
+	}
+	g.add(g)
+	for v := VtxID(1); v <= Nv; v++ {
+		for i := 0; i < open[v-1]; i++ {
+			g.edges = append(g.edges, opEdge{va: v, vb: v + Nv})
+		}
+	}
+}
+
+func (g *opGraph) replace(vtxID int64) error {
+	Nv := VtxID(g.numVtx())
+	if vtxID < 1 || vtxID > int64(Nv) {
+		return go2x3.ErrBadVtxID
+	}
+	v := VtxID(vtxID)
+	ring := [3]VtxID{v, Nv + 1, Nv + 2}
+	g.negLoops = append(g.negLoops, 0, 0)
+
+	// Each ring vertex takes one of v's slots: edges first, then negative loops (any remaining slots stay open)
+	slot := 0
+	for i := range g.edges {
+		e := &g.edges[i]
+		if e.va == v {
+			e.va = ring[slot]
+			slot++
+		} else if e.vb == v {
+			e.vb = ring[slot]
+			slot++
+		}
+	}
+	negLoops := g.negLoops[v-1]
+	g.negLoops[v-1] = 0
+	for i := byte(0); i < negLoops; i++ {
+		g.negLoops[ring[slot]-1]++
+		slot++
+	}
+
+	for i := range ring {
+		g.edges = append(g.edges, opEdge{va: ring[i], vb: ring[(i+1)%3]})
+	}
+	return nil
+}
+
+// exportTo assigns X from this opGraph, combining the unit edges connecting each pair of vertices into an EdgeType.
+func (g *opGraph) exportTo(X *Graph) error {
+	Nv := g.numVtx()
+	if Nv > MaxVtxID {
+		return go2x3.ErrBadVtxID
+	}
+
+	type pairCount struct {
+		va, vb   VtxID
+		pos, neg byte
+	}
+	pairs := map[[2]VtxID]*pairCount{}
+	vtxEdges := make([]byte, Nv)
+	for _, e := range g.edges {
+		va, vb := e.va, e.vb
+		if va > vb {
+			va, vb = vb, va
+		}
+		pc := pairs[[2]VtxID{va, vb}]
+		if pc == nil {
+			pc = &pairCount{va: va, vb: vb}
+			pairs[[2]VtxID{va, vb}] = pc
+		}
+		if e.neg {
+			pc.neg++
+		} else {
+			pc.pos++
+		}
+		vtxEdges[va-1]++
+		vtxEdges[vb-1]++
+	}
+
+	for vi := 0; vi < Nv; vi++ {
+		v := GetVtxType(g.negLoops[vi], vtxEdges[vi])
+		if v == V_nil {
+			return go2x3.ErrSitesExceeded
+		}
+		X.vtx[vi] = v
+	}
+	X.vtxCount = Nv
+
+	edges := X.edges[:0]
+	for _, pc := range pairs {
+		edgeType := EdgeType(((pc.pos + pc.neg) << 2) | pc.neg)
+		edges = append(edges, edgeType.FormEdge(pc.va, pc.vb))
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		return edges[i]&^EdgeTypeMask < edges[j]&^EdgeTypeMask
+	})
+	X.edgeCount = len(edges)
+	X.onGraphChanged()
+	return nil
+}
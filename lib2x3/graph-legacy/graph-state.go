@@ -866,6 +866,40 @@ func (X *graphState) Traces(numTraces int) go2x3.Traces {
 	return X.traces[:numTraces]
 }
 
+// CheckedTraces is Traces but returns go2x3.ErrTracesOverflow if any of the requested Traces overflow.
+// Unlike Traces, any number of Traces can be requested.
+func (X *graphState) CheckedTraces(numTraces int) (go2x3.Traces, error) {
+	numTraces = max(X.vtxCount, numTraces)
+	if numTraces > len(X.traces) {
+		return X.BigTraces(numTraces).Traces()
+	}
+	TX := X.Traces(numTraces)
+
+	// Only Traces past the magnitude bound need to be confirmed exactly
+	maxRowNorm := 0
+	for _, vj := range X.VtxByID() {
+		maxRowNorm = max(maxRowNorm, len(vj.edges))
+	}
+	if numTraces > go2x3.NumExactTraces(X.vtxCount, uint64(maxRowNorm)) {
+		if _, err := X.BigTraces(numTraces).Traces(); err != nil {
+			return TX, err
+		}
+	}
+	return TX, nil
+}
+
+// BigTraces returns the requested number of Traces (0 denotes the vertex count) computed exactly.
+func (X *graphState) BigTraces(numTraces int) go2x3.BigTraces {
+	Nv := X.vtxCount
+	A := make([]int64, Nv*Nv)
+	for j, vj := range X.VtxByID() {
+		for _, e := range vj.edges {
+			A[j*Nv+int(e.FromVtxIdx)] += int64(e.EdgeSign)
+		}
+	}
+	return go2x3.BigTracesOf(A, Nv, numTraces)
+}
+
 func max(a, b int) int {
 	if a > b {
 		return a
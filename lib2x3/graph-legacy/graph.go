@@ -1,6 +1,8 @@
 package lib2x3
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"log"
@@ -357,11 +359,28 @@ func (X *Graph) WriteCSV(out io.Writer, opts go2x3.PrintOpts) error {
 	if opts.NumTraces != 0 {
 		X.WriteTracesAsCSV(out, opts.NumTraces)
 	}
+	if len(opts.Normalize) > 0 {
+		if err := X.WriteNormalizedTracesAsCSV(out, opts.NumTraces, opts.Normalize); err != nil {
+			return err
+		}
+	}
 
-	if opts.CycleSpec {
+	if opts.CycleSpec || opts.Primitive || opts.EdgeGroup {
 		out.Write(newline)
-		X.vm.Canonize()
-		X.vm.PrintCycleSpectrum(12, out)
+		if err := X.vm.Canonize(); err != nil {
+			return err
+		}
+		if opts.CycleSpec {
+			X.vm.PrintCycleSpectrum(12, out)
+		}
+		if opts.Primitive {
+			X.vm.PrintPrimitiveCycleSpectrum(12, out)
+		}
+		if opts.EdgeGroup {
+			if err := X.vm.PrintEdgeGroupSpectrum(12, out); err != nil {
+				return err
+			}
+		}
 	}
 
 	return err
@@ -378,7 +397,29 @@ func (X *Graph) WriteTracesAsCSV(out io.Writer, numTraces int) {
 	}
 }
 
+// WriteNormalizedTracesAsCSV writes this graph's Traces normalized by each of the named TracesNormalizers.
+func (X *Graph) WriteNormalizedTracesAsCSV(out io.Writer, numTraces int, names []string) error {
+	var scrap [256]byte
+	line, err := go2x3.AppendNormalizedCSV(scrap[:0], X.Traces(numTraces), X.VertexCount(), names)
+	out.Write(line)
+	return err
+}
+
 func (X *Graph) WriteAsGraphExprStr(out io.Writer) {
+	out.Write(quote)
+	X.writeGraphExpr(out)
+	out.Write(quote)
+	out.Write(comma)
+}
+
+// AppendGraphExpr appends this graph's expression (e.g. "1-2-3-1") to the given buffer.
+func (X *Graph) AppendGraphExpr(out []byte) []byte {
+	buf := bytes.NewBuffer(out)
+	X.writeGraphExpr(buf)
+	return buf.Bytes()
+}
+
+func (X *Graph) writeGraphExpr(out io.Writer) {
 	var buf [MaxVtxID]byte
 	negLoops := buf[:X.vtxCount]
 
@@ -400,8 +441,6 @@ func (X *Graph) WriteAsGraphExprStr(out io.Writer) {
 		out.Write(s)
 	}
 
-	out.Write(quote)
-
 	// Write out single verts
 	needsBreak := false
 	for vi, v := range X.Vtx() {
@@ -456,8 +495,6 @@ func (X *Graph) WriteAsGraphExprStr(out io.Writer) {
 			needsBreak = true
 		}
 	}
-	out.Write(quote)
-	out.Write(comma)
 }
 
 func (X *Graph) WriteAsMatrixStr(out io.Writer) {
@@ -683,6 +720,57 @@ func (X *Graph) Traces(numTraces int) go2x3.Traces {
 	return X.xstate.Traces(numTraces)
 }
 
+// CheckedTraces is Traces but returns go2x3.ErrTracesOverflow if any of the requested Traces overflow an int64.
+func (X *Graph) CheckedTraces(numTraces int) (go2x3.Traces, error) {
+	X.Traces(0) // Make sure graph is flushed to X.xstate
+	return X.xstate.CheckedTraces(numTraces)
+}
+
+// BigTraces returns the requested number of Traces (0 denotes the vertex count) computed exactly.
+func (X *Graph) BigTraces(numTraces int) go2x3.BigTraces {
+	X.Traces(0) // Make sure graph is flushed to X.xstate
+	return X.xstate.BigTraces(numTraces)
+}
+
+// NonBacktrackingTraces returns the requested number of non-backtracking Traces (0 denotes the vertex count).
+func (X *Graph) NonBacktrackingTraces(numTraces int) (go2x3.Traces, error) {
+	X.Traces(0) // Make sure graph is flushed to X.vm
+
+	// Canonizing consolidates X.vm's edges, so re-export if needed
+	if X.vm.Status >= graph.GraphStatus_Canonized {
+		if err := ExportGraph(X, &X.vm); err != nil {
+			return nil, err
+		}
+	}
+	return X.vm.NonBacktrackingTraces(numTraces)
+}
+
+// EdgeGroups returns this graph's edges grouped by the cycles they contribute (see graph.VtxGraphVM.EdgeGroups).
+func (X *Graph) EdgeGroups() ([]*graph.EdgeGroup, error) {
+	X.Traces(0) // Make sure graph is flushed to X.vm
+	return X.vm.EdgeGroups()
+}
+
+// EdgeGroupDual returns this graph's edge group dual (see graph.VtxGraphVM.EdgeGroupDual).
+func (X *Graph) EdgeGroupDual() (go2x3.State, error) {
+	X.Traces(0) // Make sure graph is flushed to X.vm
+	dual, err := X.vm.EdgeGroupDual()
+	if err != nil {
+		return nil, err
+	}
+	return dual, nil
+}
+
+// WalkMatrices returns this graph's walk-count matrices A^1 .. A^numTraces (0 denotes the vertex count), either per vertex
+// or per consolidated vtx group (see graph.VtxGraphVM.WalkMatrices and GroupWalkMatrices).
+func (X *Graph) WalkMatrices(numTraces int, byGroup bool) ([]go2x3.WalkMatrix, error) {
+	X.Traces(0) // Make sure graph is flushed to X.vm
+	if byGroup {
+		return X.vm.GroupWalkMatrices(numTraces)
+	}
+	return X.vm.WalkMatrices(numTraces)
+}
+
 // PermuteVtxSigns emits a Graph for every possible vertex pole permutation of the given Graph.
 //
 // The callback handler should not make any changes to Xperm (with the exception of calling Traces())
@@ -707,7 +795,9 @@ func (X *Graph) PermuteVtxSigns(dst *go2x3.GraphStream) {
 	}
 
 	for {
-		dst.Outlet <- Xi.MakeCopy()
+		if !dst.PushGraph(Xi) {
+			return
+		}
 		permCount--
 
 		// "Increment" to the next permutation
@@ -764,7 +854,7 @@ func (X *Graph) PermuteEdgeSigns(dst *go2x3.GraphStream) {
 	// Note that X.edgeCount is vertex pair count, so 2 or 3 edges of matching type will only show up as *one* element.
 	Ne := X.edgeCount
 	if Ne == 0 {
-		dst.Outlet <- X.MakeCopy()
+		dst.PushGraph(X)
 		return
 	}
 
@@ -782,7 +872,9 @@ func (X *Graph) PermuteEdgeSigns(dst *go2x3.GraphStream) {
 	}
 
 	for {
-		dst.Outlet <- Xi.MakeCopy()
+		if !dst.PushGraph(Xi) {
+			return
+		}
 		permCount--
 
 		// "Increment" to the next permutation
@@ -823,8 +915,8 @@ func (X *Graph) PermuteEdgeSigns(dst *go2x3.GraphStream) {
 	}
 }
 
-func EnumPureParticles(opts walker.EnumOpts) *go2x3.GraphStream {
-	gw, err := NewGraphWalker()
+func EnumPureParticles(ctx context.Context, opts walker.EnumOpts) *go2x3.GraphStream {
+	gw, err := NewGraphWalker(ctx)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -844,14 +936,13 @@ type GraphWalker struct {
 	openSlotsScrap [MaxVtxID]VtxEdgeSlots
 	encoderScrap   [MaxVtxID * 4]EncoderCmd //  max cmds: max vertices + 3 edges per vertex
 	EnumStream     *go2x3.GraphStream
+	cancelled      bool // set once EnumStream is cancelled
 }
 
-func NewGraphWalker() (*GraphWalker, error) {
+func NewGraphWalker(ctx context.Context) (*GraphWalker, error) {
 
 	gw := &GraphWalker{
-		EnumStream: &go2x3.GraphStream{
-			Outlet: make(chan go2x3.State, 1),
-		},
+		EnumStream: go2x3.NewGraphStream(ctx),
 		vtxChoices: []VtxType{V_u, V_d, V_𝛾},
 	}
 
@@ -860,7 +951,7 @@ func NewGraphWalker() (*GraphWalker, error) {
 
 func (gw *GraphWalker) EnumPureParticles(Nv_lo, Nv_hi int) {
 
-	for i := Nv_lo; i <= Nv_hi; i++ {
+	for i := Nv_lo; i <= Nv_hi && !gw.cancelled; i++ {
 		gw.targetVtxCount = VtxID(i)
 		if i == 1 {
 			// Enum base case: Add the only 1x1 (positive) particle
@@ -888,6 +979,10 @@ func (gw *GraphWalker) enumAllParticles(
 	numVtxAdded VtxID,
 ) {
 
+	if gw.cancelled {
+		return
+	}
+
 	// Diagnostic
 	if numOpenEdgeSlots != int32(openSlots.CountOpenEdgeSlots()) {
 		panic("numOpenEdgeSlots check failed")
@@ -982,5 +1077,7 @@ func (gw *GraphWalker) onParticleCompleted(cmds []EncoderCmd) {
 	X := NewGraph(nil)
 	X.AssignFromCmds(cmds)
 
-	gw.EnumStream.Outlet <- X
+	if !gw.EnumStream.Send(X) {
+		gw.cancelled = true
+	}
 }
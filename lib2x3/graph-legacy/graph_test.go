@@ -1,11 +1,15 @@
 package lib2x3
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"testing"
 
 	"github.com/fine-structures/fine.SDK/go2x3"
+	"github.com/fine-structures/fine.SDK/lib2x3/graph"
+	walker "github.com/fine-structures/fine.SDK/lib2x3/graph-walker"
 )
 
 func TestBasics(t *testing.T) {
@@ -87,3 +91,472 @@ func PrintCycles(Xstr string) {
 	fmt.Println(str)
 	X.Reclaim()
 }
+
+func TestExactTraces(t *testing.T) {
+	for _, Xstr := range []string{"1^^^", "1-2=3-4=5", "1^-2-3-4-2,1-4", "1~2~3-1-4-5-2,3-6~7~4,5-8~6,7-8"} {
+		X := NewGraph(nil)
+		if err := X.InitFromString(Xstr); err != nil {
+			t.Fatal(err)
+		}
+
+		TX, err := X.CheckedTraces(24)
+		if err != nil {
+			t.Fatalf("%s: %v", Xstr, err)
+		}
+		TXbig, err := X.BigTraces(24).Traces()
+		if err != nil || !TX.IsEqual(TXbig) || len(TXbig) != 24 {
+			t.Fatalf("%s: BigTraces mismatch:\n  %v\n  %v", Xstr, TX, TXbig)
+		}
+
+		// Traces beyond C40 overflow for any graph having a vertex with 3 loops or edges
+		if _, err = X.CheckedTraces(48); err != go2x3.ErrTracesOverflow {
+			t.Fatalf("%s: expected ErrTracesOverflow, got %v", Xstr, err)
+		}
+		if TXbig := X.BigTraces(48); TXbig[47].Sign() == 0 || TXbig[47].IsInt64() {
+			t.Fatalf("%s: expected an exact C48, got %v", Xstr, TXbig[47])
+		}
+		X.Reclaim()
+	}
+}
+
+func TestCharPoly(t *testing.T) {
+	for _, Xstr := range []string{"1-2-3", "1=2-3-1", "1^-2-3-4-2,1-4", "1~2~3-1-4-5-2,3-6~7~4,5-8~6,7-8"} {
+		X := NewGraph(nil)
+		if err := X.InitFromString(Xstr); err != nil {
+			t.Fatal(err)
+		}
+
+		P, err := go2x3.GraphCharPoly(X)
+		if err != nil || P.Degree() != X.VertexCount() {
+			t.Fatalf("%s: got %v (%v)", Xstr, P, err)
+		}
+
+		// the characteristic polynomial regenerates the graph's Traces
+		TX, err := P.Traces(16)
+		if err != nil || !TX.IsEqual(X.Traces(16)) {
+			t.Fatalf("%s: got Traces %v, expected %v", Xstr, TX, X.Traces(16))
+		}
+
+		// Traces extended by recurrence match the direct computation
+		TXext, err := go2x3.ExtendedTraces(X, 24)
+		if err != nil || !TXext.IsEqual(X.Traces(24)) || len(TXext) != 24 {
+			t.Fatalf("%s: ExtendedTraces mismatch:\n  %v\n  %v (%v)", Xstr, TXext, X.Traces(24), err)
+		}
+		TXbig, err := go2x3.ExtendedBigTraces(X, 60)
+		if err != nil || TXbig.String() != X.BigTraces(60).String() {
+			t.Fatalf("%s: ExtendedBigTraces mismatch (%v)", Xstr, err)
+		}
+
+		// each vertex has at most 3 edges or loops, so no eigenvalue exceeds 3
+		radius, err := P.SpectralRadius()
+		if err != nil || radius <= 0 || radius > 3+1e-9 {
+			t.Fatalf("%s: unexpected spectral radius %v (%v)", Xstr, radius, err)
+		}
+		if _, err := P.Spectrum(); err != nil {
+			t.Fatalf("%s: %v", Xstr, err)
+		}
+		X.Reclaim()
+	}
+
+	// 1-2-3 and 1-2 1-3 are the same graph expressed differently
+	X, Y := NewGraph(nil), NewGraph(nil)
+	X.InitFromString("1-2-3")
+	Y.InitFromString("1-2,1-3")
+	if same, err := go2x3.IsCospectral(X, Y); !same || err != nil {
+		t.Fatalf("expected cospectral graphs (%v)", err)
+	}
+}
+
+func TestNonBacktrackingTraces(t *testing.T) {
+	X := NewGraph(nil)
+
+	// K4 is 3-regular, so by Ihara's theorem its non-backtracking Traces are:
+	//    Tm = Σ (α^m + β^m) + (Ne - Nv)(1 + (-1)^m)
+	// where α and β are the roots of x^2 - λx + 2 for each adjacency eigenvalue λ (3, -1, -1, -1).
+	const numTraces = 16
+	expect := make(go2x3.Traces, numTraces)
+	p0, p1 := int64(2), int64(-1) // power sums of the roots of x^2 + x + 2
+	for m := 1; m <= numTraces; m++ {
+		expect[m-1] = 1 + 1<<m + 3*p1 + 2*(1+int64(1-2*(m&1)))
+		p0, p1 = p1, -p1-2*p0
+	}
+	if expect[2] != 24 || expect[3] != 24 { // 4 triangles and 3 squares, each in 2 directions from each start
+		t.Fatalf("bad expected Traces %v", expect)
+	}
+
+	X.InitFromString("1-2-3-1,1-4,2-4,3-4")
+	TX, err := X.NonBacktrackingTraces(numTraces)
+	if err != nil || !TX.IsEqual(expect) {
+		t.Fatalf("K4: got %v, expected %v (%v)", TX, expect, err)
+	}
+
+	// negating every edge negates each odd-length walk
+	X.InitFromString("1~2~3~1,1~4,2~4,3~4")
+	X.Traces(0) // canonizes, so the graph must be re-exported
+	TX, err = X.NonBacktrackingTraces(numTraces)
+	for i := range expect {
+		if i&1 == 0 {
+			expect[i] = -expect[i]
+		}
+	}
+	if err != nil || !TX.IsEqual(expect) {
+		t.Fatalf("negated K4: got %v, expected %v (%v)", TX, expect, err)
+	}
+
+	NB := go2x3.NonBacktracking(X)
+	if NB == nil || NB.VertexCount() != 4 || !NB.Traces(numTraces).IsEqual(TX) || go2x3.NonBacktracking(go2x3.Traces{1}) != nil {
+		t.Fatal("NonBacktracking wrapper fail")
+	}
+}
+
+func TestEdgeGroups(t *testing.T) {
+	for _, Xstr := range []string{"1-2-3", "1=2-3-1", "1^-2-3-4-2,1-4", "1-2-3-1,1-4,2-4,3-4", "1~2-3-4-5-6-1"} {
+		X := NewGraph(nil)
+		if err := X.InitFromString(Xstr); err != nil {
+			t.Fatal(err)
+		}
+		groups, err := X.EdgeGroups()
+		if err != nil || len(groups) == 0 {
+			t.Fatalf("%s: no edge groups (%v)", Xstr, err)
+		}
+
+		// the edge groups account for all the graph's Traces
+		TX := X.Traces(12)
+		sum := make(go2x3.Traces, len(TX))
+		for gi, eg := range groups {
+			if eg.GroupID != uint32(gi+1) || int(eg.Count) != len(eg.VtxIDs) {
+				t.Fatalf("%s: bad edge group %d", Xstr, gi)
+			}
+			for i := range sum {
+				sum[i] += eg.Count * eg.Cycles[i]
+			}
+		}
+		if !sum.IsEqual(TX) {
+			t.Fatalf("%s: edge groups sum to %v, expected %v", Xstr, sum, TX)
+		}
+	}
+
+	// every edge of an edge-transitive graph has the same cycles, so K4 and K3,3 each form a single group
+	X := NewGraph(nil)
+	for Xstr, numHalves := range map[string]int64{
+		"1-2-3-1,1-4,2-4,3-4":                 12,
+		"1-4,1-5,1-6,2-4,2-5,2-6,3-4,3-5,3-6": 18,
+	} {
+		X.InitFromString(Xstr)
+		groups, err := X.EdgeGroups()
+		if err != nil || len(groups) != 1 || groups[0].Count != numHalves {
+			t.Fatalf("%s: expected a single edge group of %d (%v)", Xstr, numHalves, err)
+		}
+	}
+}
+
+func TestEdgeGroupDual(t *testing.T) {
+	X := NewGraph(nil)
+
+	// K4 has a single edge group touching all 4 vertices, so its dual is a single vertex having a loop of weight 4
+	X.InitFromString("1-2-3-1,1-4,2-4,3-4")
+	dual, err := X.EdgeGroupDual()
+	if err != nil || dual.VertexCount() != 1 || !dual.Traces(3).IsEqual(go2x3.Traces{4, 16, 64}) {
+		t.Fatalf("K4 dual: got %v (%v)", dual.Traces(3), err)
+	}
+	if dual2, err := dual.(go2x3.EdgeGroupDualProvider).EdgeGroupDual(); err != nil || !dual2.Traces(3).IsEqual(go2x3.Traces{1, 1, 1}) {
+		t.Fatalf("K4 dual of dual: (%v)", err)
+	}
+
+	for _, Xstr := range []string{"1-2-3", "1=2-3-1", "1^-2-3-4-2,1-4", "1~2-3-4-5-6-1", "1-2,3-4"} {
+		X.InitFromString(Xstr)
+		groups, err := X.EdgeGroups()
+		if err != nil {
+			t.Fatal(err)
+		}
+		dual, err := X.EdgeGroupDual()
+		if err != nil || dual.VertexCount() != len(groups) {
+			t.Fatalf("%s: expected a dual vertex per edge group (%v)", Xstr, err)
+		}
+		if info := dual.GraphInfo(); info.NumParticles != X.NumParticles() {
+			t.Fatalf("%s: dual has %d particles, expected %d", Xstr, info.NumParticles, X.NumParticles())
+		}
+
+		// the dual is a graph like any other
+		P, err := go2x3.GraphCharPoly(dual)
+		if err != nil || P.Degree() != len(groups) {
+			t.Fatalf("%s: dual char poly %v (%v)", Xstr, P, err)
+		}
+		dual.Reclaim()
+	}
+
+	// a stream of graphs becomes a stream of their duals
+	ctx := context.Background()
+	X.InitFromString("1-2-3-1,1-4,2-4,3-4")
+	stream := go2x3.StreamGraph(ctx, X.MakeCopy()).EdgeGroupDual(ctx, go2x3.StageOpts{})
+	count := 0
+	for dual := range stream.All() {
+		if dual.VertexCount() != 1 {
+			t.Fatal("bad dual")
+		}
+		count++
+	}
+	if count != 1 || stream.Err() != nil {
+		t.Fatalf("expected 1 dual, got %d (%v)", count, stream.Err())
+	}
+}
+
+func TestWalkMatrices(t *testing.T) {
+	X := NewGraph(nil)
+	for _, Xstr := range []string{"1-2-3", "1=2-3-1", "1^-2-3-4-2,1-4", "1-2-3-1,1-4,2-4,3-4", "1~2-3-4-5-6-1", "1-2,3-4"} {
+		X.InitFromString(Xstr)
+		TX := X.Traces(8)
+
+		Ms, err := X.WalkMatrices(8, false)
+		if err != nil || len(Ms) != 8 {
+			t.Fatalf("%s: expected 8 walk matrices (%v)", Xstr, err)
+		}
+		groupMs, err := X.WalkMatrices(8, true)
+		if err != nil || len(groupMs) != 8 {
+			t.Fatalf("%s: expected 8 group walk matrices (%v)", Xstr, err)
+		}
+		for k, M := range Ms {
+			if M.K != k+1 || len(M.Walks) != X.VertexCount() {
+				t.Fatalf("%s: bad walk matrix A^%d", Xstr, k+1)
+			}
+
+			// the diagonal of A^k counts the closed walks, so its trace is Tk
+			if M.Trace() != TX[k] {
+				t.Fatalf("%s: A^%d trace is %d, expected %d", Xstr, k+1, M.Trace(), TX[k])
+			}
+
+			// a walk reversed is also a walk, so A^k is symmetric and grouping preserves the total walk count
+			sum, groupSum := int64(0), int64(0)
+			for i, row := range M.Walks {
+				for j, Wij := range row {
+					if Wij != M.Walks[j][i] {
+						t.Fatalf("%s: A^%d is not symmetric", Xstr, k+1)
+					}
+					sum += Wij
+				}
+			}
+			for _, row := range groupMs[k].Walks {
+				for _, Wab := range row {
+					groupSum += Wab
+				}
+			}
+			if sum != groupSum {
+				t.Fatalf("%s: A^%d sums to %d but grouped sums to %d", Xstr, k+1, sum, groupSum)
+			}
+		}
+	}
+
+	// all the vertices of K4 consolidate into one group, where each vertex has 3 neighbors
+	X.InitFromString("1-2-3-1,1-4,2-4,3-4")
+	Ms, err := X.WalkMatrices(2, true)
+	if err != nil || len(Ms[0].Walks) != 1 || Ms[0].Walks[0][0] != 12 || Ms[1].Walks[0][0] != 36 {
+		t.Fatalf("K4 group walks: %v (%v)", Ms, err)
+	}
+	if Ms[0].Labels[0] != "1.A" {
+		t.Fatalf("K4 group label: %q", Ms[0].Labels[0])
+	}
+}
+
+const prism = "1-2-3-1,4-5-6-4,1-4,2-5,3-6"
+
+// checkGraph fails if X does not have the vertex count and traces of the given graph expr.
+func checkGraph(t *testing.T, X *Graph, expect string) {
+	t.Helper()
+	Xexpect := NewGraph(nil)
+	defer Xexpect.Reclaim()
+	if err := Xexpect.InitFromString(expect); err != nil {
+		t.Fatal(err)
+	}
+	if TX := X.Traces(0); X.VertexCount() != Xexpect.VertexCount() || TX.Compare(Xexpect.Traces(0)) != 0 {
+		t.Fatalf("%s: got %v, expected %s", X.AppendGraphExpr(nil), TX, expect)
+	}
+}
+
+func TestOpNode(t *testing.T) {
+	leaf := func(expr string) *graph.OpNode {
+		return &graph.OpNode{GraphExpr: expr}
+	}
+	const K4 = "1-2-3-1,1-4,2-4,3-4"
+
+	tests := []struct {
+		op     *graph.OpNode
+		expect string
+	}{
+		{&graph.OpNode{OpCode: graph.OpCode_Sum, Components: []*graph.OpNode{leaf("1-2"), leaf("1^")}}, "1-2; 1^"},
+		{&graph.OpNode{OpCode: graph.OpCode_Power, Repeat: 3, Components: []*graph.OpNode{leaf("1-2")}}, "1-2; 1-2; 1-2"},
+		{&graph.OpNode{OpCode: graph.OpCode_Power, Repeat: 3, VtxID: 1, GraphExpr: "1"}, "1-2-3-1"},
+		{&graph.OpNode{OpCode: graph.OpCode_Power, Repeat: 2, VtxID: 1, GraphExpr: "1-2"}, "2-1=3-4"},
+		{&graph.OpNode{OpCode: graph.OpCode_Power, Repeat: 4, VtxID: 2, GraphExpr: "1=2"}, "1=2-3=4-5=6-7=8-1"},
+		{&graph.OpNode{OpCode: graph.OpCode_Mirror, GraphExpr: "1"}, "1---2"},
+		{&graph.OpNode{OpCode: graph.OpCode_Mirror, GraphExpr: "1-2-3-1"}, prism},
+		{&graph.OpNode{OpCode: graph.OpCode_Mirror, Components: []*graph.OpNode{
+			{OpCode: graph.OpCode_Power, Repeat: 4, VtxID: 1, GraphExpr: "1"},
+		}}, "1-2-3-4-1,5-6-7-8-5,1-5,2-6,3-7,4-8"},
+		{&graph.OpNode{OpCode: graph.OpCode_Replace, VtxID: 1, GraphExpr: K4}, prism},
+		{&graph.OpNode{OpCode: graph.OpCode_Replace, VtxID: 1, GraphExpr: "1^"}, "1^-2-3-1"},
+		{&graph.OpNode{OpCode: graph.OpCode_Replace, VtxID: 2, GraphExpr: "1~2-3"}, "1~2-4-5-2,4-3"},
+	}
+
+	X := NewGraph(nil)
+	for _, test := range tests {
+		if err := X.InitFromOpNode(test.op); err != nil {
+			t.Fatalf("%v: %v", test.op, err)
+		}
+		checkGraph(t, X, test.expect)
+	}
+
+	errTests := []struct {
+		op  *graph.OpNode
+		err error
+	}{
+		{&graph.OpNode{OpCode: graph.OpCode_Sum}, go2x3.ErrBadOpNode},
+		{&graph.OpNode{OpCode: graph.OpCode_Power, GraphExpr: "1"}, go2x3.ErrBadOpNode},
+		{&graph.OpNode{OpCode: graph.OpCode_Power, Repeat: 32, GraphExpr: "1"}, go2x3.ErrBadVtxID},
+		{&graph.OpNode{OpCode: graph.OpCode_Power, Repeat: 2, VtxID: 1, GraphExpr: K4}, go2x3.ErrSitesExceeded},
+		{&graph.OpNode{OpCode: graph.OpCode_Replace, VtxID: 5, GraphExpr: K4}, go2x3.ErrBadVtxID},
+		{&graph.OpNode{OpCode: graph.OpCode(99), GraphExpr: "1"}, go2x3.ErrBadOpNode},
+	}
+	for _, test := range errTests {
+		if err := X.InitFromOpNode(test.op); err != test.err {
+			t.Fatalf("%v: expected %v, got %v", test.op, test.err, err)
+		}
+	}
+}
+
+func TestOpExpr(t *testing.T) {
+	tests := []struct {
+		opExpr string
+		expect string
+	}{
+		{"sum(p+, e-)", "1-2-3; 1"},
+		{"mirror(1-2-3-1)", prism},
+		{"replace(1, tetra)", prism},
+		{`replace(1, "1-2-3-1,1-4,2-4,3-4")`, prism},
+		{"power(4:2, 1=2)", "1=2-3=4-5=6-7=8-1"},
+		{"mirror(power(4:1, 1))", NamedGraphs["higgs"]},
+		{"sum(1^-2~3, power(2, gamma))", "1^-2~3; 1---2; 1---2"},
+		{"1-2-3-1,1-4", "1-2-3-1,1-4"}, // graph expressions are still read as before
+	}
+
+	X := NewGraph(nil)
+	for _, test := range tests {
+		if err := X.InitFromExpr(test.opExpr); err != nil {
+			t.Fatalf("%s: %v", test.opExpr, err)
+		}
+		checkGraph(t, X, test.expect)
+		if len(X.Def.GraphExprs) != 1 || X.Def.GraphExprs[0] != test.opExpr {
+			t.Fatalf("%s: bad GraphExprs %v", test.opExpr, X.Def.GraphExprs)
+		}
+	}
+
+	for _, bad := range []string{"mirror(", "bogus", "power(0, 1)", "replace(2, 1)"} {
+		if err := X.InitFromExpr(bad); err == nil {
+			t.Fatalf("%s: expected error", bad)
+		}
+	}
+
+	// graph names can't be mistaken for ops or graph expressions
+	for name := range NamedGraphs {
+		if err := graph.CheckGraphName(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, bad := range []string{"sum", "mirror", "power", "replace", "4x"} {
+		if err := RegisterNamedGraph(bad, "1-2"); !errors.Is(err, go2x3.ErrBadOpNode) {
+			t.Fatalf("%s: expected ErrBadOpNode, got %v", bad, err)
+		}
+	}
+	if err := RegisterNamedGraph("tri", "1-2-3-1"); err != nil {
+		t.Fatal(err)
+	}
+	defer delete(NamedGraphs, "tri")
+	if err := X.InitFromExpr("mirror(tri)"); err != nil {
+		t.Fatal(err)
+	}
+	checkGraph(t, X, prism)
+}
+
+func TestGraphCodecs(t *testing.T) {
+	X := NewGraph(nil)
+	X.InitFromString("1-2-3-1,1-4^")
+
+	// a Graph is only expressible as a graph expression
+	str, err := graph.FormatGraph(X, graph.EncodingForm_AutoDetect)
+	if err != nil || str != strings.ReplaceAll(string(X.AppendGraphExpr(nil)), " ", ",") {
+		t.Fatalf("GraphExpr: %q (%v)", str, err)
+	}
+	if _, err = graph.FormatGraph(X, graph.EncodingForm_EnumerationID); err != go2x3.ErrNotSupported {
+		t.Fatalf("EnumerationID: expected ErrNotSupported, got %v", err)
+	}
+	for _, expr := range []string{str, "mirror(1-2-3-1)"} {
+		Xd, err := graph.DecodeGraph([]byte(expr), graph.EncodingForm_AutoDetect)
+		if err != nil {
+			t.Fatalf("%s: %v", expr, err)
+		}
+		Xexpect := NewGraph(nil)
+		Xexpect.InitFromExpr(expr)
+		if !Xd.Traces(0).IsEqual(Xexpect.Traces(0)) {
+			t.Fatalf("%s: decoded %v", expr, Xd.Traces(0))
+		}
+	}
+
+	if form, err := graph.ParseEncodingForm("EnumerationID"); err != nil || form != graph.EncodingForm_EnumerationID {
+		t.Fatalf("ParseEncodingForm: %v (%v)", form, err)
+	}
+	if _, err := graph.ParseEncodingForm("bogus"); err == nil {
+		t.Fatal("ParseEncodingForm: expected error")
+	}
+
+	// a query accepts a graph in any form
+	X.InitFromExpr("mirror(1-2-3-1)")
+	sel, err := go2x3.ParseQuery(`v <= 8 && traces == "mirror(1-2-3-1)"`)
+	if err != nil || sel.Traces == nil || !sel.Traces.Traces(0).IsEqual(X.Traces(0)) {
+		t.Fatalf("ParseQuery: %v", err)
+	}
+
+	// every enumerated Construction reads back as an equivalent Graph from each of its forms
+	stream, err := walker.EnumPureParticles(context.Background(), walker.EnumOpts{
+		VertexMax: 5,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := 0
+	for Xc := range stream.All() {
+		for _, form := range []graph.EncodingForm{graph.EncodingForm_GraphExpr, graph.EncodingForm_BinaryOpString, graph.EncodingForm_EnumerationID} {
+			str, err := graph.FormatGraph(Xc, form)
+			if err != nil {
+				t.Fatalf("%v: %v", form, err)
+			}
+			if err = X.InitFromExpr(str); err != nil {
+				t.Fatalf("%v %s: %v", form, str, err)
+			}
+			if X.VertexCount() != Xc.VertexCount() || !X.Traces(0).IsEqual(Xc.Traces(0)) {
+				t.Fatalf("%v %s: got %v, expected %v", form, str, X.Traces(0), Xc.Traces(0))
+			}
+		}
+		count++
+	}
+	if count == 0 || stream.Err() != nil {
+		t.Fatalf("enumerated %d graphs (%v)", count, stream.Err())
+	}
+}
+
+// TestExportGraphEncoding checks that an exported legacy graph is encoded like any VtxGraphVM (see lib2x3/graph).
+func TestExportGraphEncoding(t *testing.T) {
+
+	// K4 consolidates to a single group
+	X := NewGraph(nil)
+	X.InitFromString("1-2-3-1,1-4,2-4,3-4")
+	var vm graph.VtxGraphVM
+	if err := ExportGraph(X, &vm); err != nil {
+		t.Fatal(err)
+	}
+	enc, err := vm.AppendGraphEncoding(nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if groups, err := graph.DecodeGraphEncoding(enc); err != nil || len(groups) != 1 || groups[0].Count != 4 || groups[0].Edges[0].Count != 12 {
+		t.Fatalf("K4 encoding %x (%v)", enc, err)
+	}
+}
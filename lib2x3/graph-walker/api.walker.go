@@ -1,13 +1,17 @@
 package walker
 
 import (
+	"context"
+
 	"github.com/fine-structures/fine.SDK/go2x3"
 	"github.com/fine-structures/fine.SDK/lib2x3/graph"
 )
 
 // Primary entry point for "2x3" graph enumeration aka "fine structures"
-func EnumPureParticles(opts EnumOpts) (*go2x3.GraphStream, error) {
-	return enumPureParticles(opts)
+//
+// Enumeration stops early once ctx is done or the returned stream is cancelled.
+func EnumPureParticles(ctx context.Context, opts EnumOpts) (*go2x3.GraphStream, error) {
+	return enumPureParticles(ctx, opts)
 }
 
 // OpCode is a graph-building op code, specifying a way to grow a 2x3 graph.
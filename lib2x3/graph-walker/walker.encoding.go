@@ -0,0 +1,182 @@
+package walker
+
+import (
+	"encoding/binary"
+
+	"github.com/fine-structures/fine.SDK/go2x3"
+	"github.com/fine-structures/fine.SDK/lib2x3/graph"
+)
+
+// A Construction is fully specified by its GrowOps, so it has two binary forms (see graph.EncodingForm):
+//
+//	BinaryOpString      each GrowOp as 4 bytes:  OpCode, Count, FromVtx, FromSlot
+//
+//	EnumerationID       each GrowOp following the initial vertex as a uvarint:  ((FromVtx-1)*3 + FromSlot-1) << 1 | (OpCode-1)
+//
+// An EnumerationID is compact and canonic for the Constructions emitted by EnumPureParticles since each graph is only
+// emitted once, by the first sequence of ops that forms it.  Only ops having a Count of 1 can be expressed as an EnumerationID.
+//
+// Each encoding is preceded by its EncodingForm (as a byte) so that it can be identified (see graph.SniffEncodingForm).
+
+func init() {
+	graph.RegisterCodec(opStringCodec{})
+	graph.RegisterCodec(enumIDCodec{})
+}
+
+// AppendOpString appends this Construction's GrowOps in BinaryOpString form (excluding the leading EncodingForm byte).
+func (X *Construction) AppendOpString(out []byte) []byte {
+	for _, op := range X.Ops {
+		out = append(out, byte(op.OpCode), byte(op.Count), byte(op.FromVtx), op.FromSlot)
+	}
+	return out
+}
+
+// AppendEnumerationID appends this Construction's GrowOps in EnumerationID form (excluding the leading EncodingForm byte).
+// Since that form starts from a single vertex, the first op must sprout one vertex from vertex 0 (as EnumPureParticles does).
+// Returns go2x3.ErrNotSupported (and out unchanged) if an op can't be expressed in this form.
+func (X *Construction) AppendEnumerationID(out []byte) ([]byte, error) {
+	if len(X.Ops) == 0 {
+		return out, go2x3.ErrNotSupported
+	}
+	if op := X.Ops[0]; op.OpCode != OpCode_Sprout || op.Count != 1 || op.FromVtx != 0 {
+		return out, go2x3.ErrNotSupported
+	}
+	start := len(out)
+	for _, op := range X.Ops[1:] {
+		if op.Count != 1 || op.FromVtx == 0 || op.FromSlot == 0 || op.FromSlot > graph.EdgesPerVertex {
+			return out[:start], go2x3.ErrNotSupported
+		}
+		ord := (uint64(op.FromVtx)-1)*graph.EdgesPerVertex + uint64(op.FromSlot) - 1
+		switch op.OpCode {
+		case OpCode_AddEdge:
+			out = binary.AppendUvarint(out, ord<<1)
+		case OpCode_Sprout:
+			out = binary.AppendUvarint(out, ord<<1|1)
+		default:
+			return out[:start], go2x3.ErrNotSupported
+		}
+	}
+	return out, nil
+}
+
+// NewConstructionFromOpString returns a new Construction formed by the given GrowOps in BinaryOpString form.
+func NewConstructionFromOpString(opStr []byte) (*Construction, error) {
+	if len(opStr)%4 != 0 {
+		return nil, go2x3.ErrBadEncoding
+	}
+	ops := make([]GrowOp, len(opStr)/4)
+	for i := range ops {
+		b := opStr[4*i:]
+		ops[i] = GrowOp{
+			OpCode:   OpCode(b[0]),
+			Count:    int8(b[1]),
+			FromVtx:  graph.VtxID(b[2]),
+			FromSlot: b[3],
+		}
+	}
+	return newConstructionFromOps(ops)
+}
+
+// NewConstructionFromEnumerationID returns a new Construction formed by the given GrowOps in EnumerationID form.
+func NewConstructionFromEnumerationID(id []byte) (*Construction, error) {
+	ops := []GrowOp{{
+		OpCode: OpCode_Sprout,
+		Count:  1,
+	}}
+	for len(id) > 0 {
+		ord, n := binary.Uvarint(id)
+		if n <= 0 || ord>>1 >= go2x3.MaxVtxID*graph.EdgesPerVertex {
+			return nil, go2x3.ErrBadEncoding
+		}
+		id = id[n:]
+
+		op := GrowOp{
+			OpCode:   OpCode_AddEdge,
+			Count:    1,
+			FromVtx:  graph.VtxID(ord>>1/graph.EdgesPerVertex + 1),
+			FromSlot: uint8(ord>>1%graph.EdgesPerVertex + 1),
+		}
+		if ord&1 != 0 {
+			op.OpCode = OpCode_Sprout
+		}
+		ops = append(ops, op)
+	}
+	return newConstructionFromOps(ops)
+}
+
+// newConstructionFromOps replays the given GrowOps, returning go2x3.ErrBadEncoding if an op can't be applied.
+func newConstructionFromOps(ops []GrowOp) (*Construction, error) {
+	if len(ops) == 0 {
+		return nil, go2x3.ErrBadEncoding
+	}
+	X := NewState(nil)
+	for i, op := range ops {
+		ok := true
+		switch {
+		case op.OpCode != OpCode_AddEdge && op.OpCode != OpCode_Sprout:
+			ok = false
+		case i == 0:
+			ok = op.OpCode == OpCode_Sprout && op.FromVtx == 0
+		case int(op.FromVtx) > len(X.Vtx):
+			ok = false
+		case op.OpCode == OpCode_Sprout && len(X.Vtx) >= go2x3.MaxVtxID:
+			ok = false
+		}
+		if ok {
+			ok = X.applyOp(op)
+		}
+		if !ok {
+			X.Reclaim()
+			return nil, go2x3.ErrBadEncoding
+		}
+		X.Ops = append(X.Ops, op)
+	}
+	return X, nil
+}
+
+type opStringCodec struct{}
+
+func (opStringCodec) EncodingForm() graph.EncodingForm {
+	return graph.EncodingForm_BinaryOpString
+}
+
+func (opStringCodec) AppendEncoding(out []byte, X go2x3.State) ([]byte, error) {
+	Xc, ok := X.(*Construction)
+	if !ok || len(Xc.Ops) == 0 {
+		return out, go2x3.ErrNotSupported
+	}
+	out = append(out, byte(graph.EncodingForm_BinaryOpString))
+	return Xc.AppendOpString(out), nil
+}
+
+func (opStringCodec) Decode(enc []byte) (go2x3.State, error) {
+	if len(enc) == 0 || enc[0] != byte(graph.EncodingForm_BinaryOpString) {
+		return nil, go2x3.ErrBadEncoding
+	}
+	return NewConstructionFromOpString(enc[1:])
+}
+
+type enumIDCodec struct{}
+
+func (enumIDCodec) EncodingForm() graph.EncodingForm {
+	return graph.EncodingForm_EnumerationID
+}
+
+func (enumIDCodec) AppendEncoding(out []byte, X go2x3.State) ([]byte, error) {
+	Xc, ok := X.(*Construction)
+	if !ok {
+		return out, go2x3.ErrNotSupported
+	}
+	enc, err := Xc.AppendEnumerationID(append(out, byte(graph.EncodingForm_EnumerationID)))
+	if err != nil {
+		return out, err
+	}
+	return enc, nil
+}
+
+func (enumIDCodec) Decode(enc []byte) (go2x3.State, error) {
+	if len(enc) == 0 || enc[0] != byte(graph.EncodingForm_EnumerationID) {
+		return nil, go2x3.ErrBadEncoding
+	}
+	return NewConstructionFromEnumerationID(enc[1:])
+}
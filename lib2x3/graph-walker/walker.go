@@ -1,6 +1,7 @@
 package walker
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"sync"
@@ -13,7 +14,7 @@ import (
 	//"github.com/fine-structures/fine.SDK/lib2x3/catalog"
 )
 
-func enumPureParticles(opts EnumOpts) (*go2x3.GraphStream, error) {
+func enumPureParticles(ctx context.Context, opts EnumOpts) (*go2x3.GraphStream, error) {
 	//ctx := go2x3.NewCatalogContext()
 	tableOpts := memory_table.DefaultOpts()
 	emitted, err := tableOpts.CreateTable()
@@ -24,9 +25,7 @@ func enumPureParticles(opts EnumOpts) (*go2x3.GraphStream, error) {
 		opts:          opts,
 		walkingVertex: 1,
 		emitted:       emitted,
-		EnumStream: &go2x3.GraphStream{
-			Outlet: make(chan go2x3.State, 1),
-		},
+		EnumStream:    go2x3.NewGraphStream(ctx),
 	}
 
 	// Enqueue a single vertex particle
@@ -80,9 +79,33 @@ func (X *Construction) Canonize(normalize bool) error {
 	return nil
 }
 
+// MarshalOut marshals this Construction as a graph.VtxState (see graph.VtxState.MarshalOut).
 func (X *Construction) MarshalOut(out []byte, opts go2x3.MarshalOpts) ([]byte, error) {
-	// TODO:
-	panic("not implemented")
+	Xs := X.VtxState()
+	defer Xs.Reclaim()
+	return Xs.MarshalOut(out, opts)
+}
+
+// VtxState returns a new graph.VtxState holding this Construction's graph.
+func (X *Construction) VtxState() *graph.VtxState {
+	Xs := graph.NewVtxState(nil)
+	for _, vj := range X.Vtx {
+		for _, vj_e := range vj.Edges {
+			weight := int64(1)
+			if vj_e.Sign < 0 {
+				weight = -1
+			}
+
+			// Each edge appears on both of its vertices, so only add it from its lower vertex (a loop has no remote vertex)
+			switch {
+			case vj_e.To == 0:
+				Xs.AddEdge(weight, uint32(vj.ID), uint32(vj.ID))
+			case vj_e.To > vj.ID:
+				Xs.AddEdge(weight, uint32(vj.ID), uint32(vj_e.To))
+			}
+		}
+	}
+	return Xs
 }
 
 func (X *Construction) WriteCSV(out io.Writer, opts go2x3.PrintOpts) error {
@@ -113,9 +136,19 @@ func (X *Construction) WriteCSV(out io.Writer, opts go2x3.PrintOpts) error {
 	if opts.NumTraces != 0 {
 		X.WriteTracesAsCSV(out, opts.NumTraces)
 	}
+	if len(opts.Normalize) > 0 {
+		return X.WriteNormalizedTracesAsCSV(out, opts.NumTraces, opts.Normalize)
+	}
 	return nil
 }
 
+func (X *Construction) AppendGraphExpr(out []byte) []byte {
+	if len(X.Vtx) == 0 {
+		return out
+	}
+	return X.marshalAsExpr(out, 1, true)
+}
+
 func (X *Construction) marshalAsExpr(out []byte, vtxID graph.VtxID, asAscii bool) []byte {
 	out = append(out, '(')
 
@@ -213,7 +246,7 @@ func (X *Construction) ParticleCount() int64 {
 }
 
 func (X *Construction) PermuteVtxSigns(dst *go2x3.GraphStream) {
-	panic("legacy: will not implement")
+	dst.Fail(go2x3.NewGraphError(go2x3.ErrNotSupported, X)) // legacy: will not implement
 }
 
 // PermuteEdgeSigns emits a Graph for every possible edge sign permutation of the given Graph.
@@ -221,7 +254,7 @@ func (X *Construction) PermuteVtxSigns(dst *go2x3.GraphStream) {
 // The callback handler should not make any changes to Xperm (with the exception of calling Traces())
 func (X *Construction) PermuteEdgeSigns(dst *go2x3.GraphStream) {
 
-	dst.Outlet <- X.MakeCopy() // TODO
+	dst.PushGraph(X) // TODO
 
 	/*
 		// If there's no edges to permute over, export only the given graph (which is just 0 or more single vertex particles).
@@ -354,6 +387,43 @@ func (X *Construction) Traces(numTraces int) go2x3.Traces {
 	return TX
 }
 
+// CheckedTraces is Traces but returns go2x3.ErrTracesOverflow if any of the requested Traces overflow an int64.
+func (X *Construction) CheckedTraces(numTraces int) (go2x3.Traces, error) {
+	TX := X.Traces(numTraces)
+
+	// Only Traces past the magnitude bound need to be confirmed exactly
+	maxRowNorm := 0
+	for _, vj := range X.Vtx {
+		maxRowNorm = max(maxRowNorm, len(vj.Edges))
+	}
+	if len(TX) > go2x3.NumExactTraces(X.VertexCount(), uint64(maxRowNorm)) {
+		if _, err := X.BigTraces(len(TX)).Traces(); err != nil {
+			return TX, err
+		}
+	}
+	return TX, nil
+}
+
+// BigTraces returns the requested number of Traces (0 denotes the vertex count) computed exactly.
+func (X *Construction) BigTraces(numTraces int) go2x3.BigTraces {
+	Nv := X.VertexCount()
+	A := make([]int64, Nv*Nv)
+	for j, vj := range X.Vtx {
+		for _, vj_e := range vj.Edges {
+			v_src := vj_e.To
+			if v_src == 0 {
+				v_src = vj.ID
+			}
+			if vj_e.Sign < 0 {
+				A[j*Nv+int(v_src)-1]--
+			} else {
+				A[j*Nv+int(v_src)-1]++
+			}
+		}
+	}
+	return go2x3.BigTracesOf(A, Nv, numTraces)
+}
+
 func (X *Construction) MakeCopy() go2x3.State {
 	return NewState(X)
 }
@@ -388,6 +458,14 @@ func (X *Construction) WriteTracesAsCSV(out io.Writer, numTraces int) {
 	}
 }
 
+// WriteNormalizedTracesAsCSV writes this graph's Traces normalized by each of the named TracesNormalizers.
+func (X *Construction) WriteNormalizedTracesAsCSV(out io.Writer, numTraces int, names []string) error {
+	var scrap [256]byte
+	line, err := go2x3.AppendNormalizedCSV(scrap[:0], X.Traces(numTraces), X.VertexCount(), names)
+	out.Write(line)
+	return err
+}
+
 // Recycles this State instance into a pool for reuse.
 // Caller asserts that no more references to this instance will persist.
 func (X *Construction) Reclaim() {
@@ -601,6 +679,12 @@ func (queue *GraphQueue) Enqueue(X *Construction) {
 	queue.Count++
 }
 
+// Reclaim releases every Construction in the queue, leaving it empty.
+func (queue *GraphQueue) Reclaim() {
+	queue.Head.Reclaim()
+	*queue = GraphQueue{}
+}
+
 func (queue *GraphQueue) Dequeue() *Construction {
 	X := queue.Head
 	if X == nil {
@@ -741,9 +825,15 @@ func (gw *graphWalker) emitSubParticles() {
 		gw.sproutEdges(X)
 
 		// after emitting all possible forks, emit outward
-		gw.EnumStream.Outlet <- X
+		if !gw.EnumStream.Send(X) {
+			break
+		}
 	}
 
+	// If cancelled, release everything still queued
+	gw.walkingQueue.Reclaim()
+	gw.deferredQueue.Reclaim()
+
 	gw.EnumStream.Close()
 }
 
@@ -1,15 +1,20 @@
 package walker
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/fine-structures/fine.SDK/go2x3"
+	"github.com/fine-structures/fine.SDK/lib2x3/graph"
 )
 
 func TestEnum(t *testing.T) {
-	stream, err := EnumPureParticles(EnumOpts{
+	stream, err := EnumPureParticles(context.Background(), EnumOpts{
 		VertexMax: 8,
 	})
 	if err != nil {
@@ -37,3 +42,398 @@ func TestEnum(t *testing.T) {
 	}
 
 }
+
+func TestEnumCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := EnumPureParticles(ctx, EnumOpts{
+		VertexMax: 10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Take a few graphs and then cancel -- the stream must close without being read to completion
+	canonized := stream.Canonize(ctx, false, go2x3.StageOpts{})
+	for i := 0; i < 5; i++ {
+		X := canonized.PullGraph()
+		if X == nil {
+			t.Fatal("stream closed early")
+		}
+		X.Reclaim()
+	}
+	canonized.Cancel()
+
+	select {
+	case <-stream.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("upstream not cancelled")
+	}
+}
+
+func TestEnumFailure(t *testing.T) {
+	ctx := context.Background()
+
+	stream, err := EnumPureParticles(ctx, EnumOpts{
+		VertexMax: 6,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Construction doesn't support vtx sign permutation, so the stage should fail (rather than panic)
+	permuted := stream.PermuteVtxSigns(ctx)
+	permuted.PullAll()
+
+	err = permuted.Err()
+	if !errors.Is(err, go2x3.ErrNotSupported) {
+		t.Fatalf("expected ErrNotSupported, got %v", err)
+	}
+	var graphErr *go2x3.GraphError
+	if !errors.As(err, &graphErr) || graphErr.Graph == "" {
+		t.Fatalf("expected graph expression with error, got %v", err)
+	}
+}
+
+func TestParallelStages(t *testing.T) {
+	ctx := context.Background()
+
+	enumExprs := func(opts go2x3.StageOpts) []string {
+		stream, err := EnumPureParticles(ctx, EnumOpts{
+			VertexMax: 7,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var exprs []string
+		prefetched := stream.PrefetchTraces(ctx, 8, opts).Canonize(ctx, false, opts)
+		for X := range prefetched.Outlet {
+			exprs = append(exprs, go2x3.GraphExpr(X))
+			X.Reclaim()
+		}
+		if err := prefetched.Err(); err != nil {
+			t.Fatal(err)
+		}
+		return exprs
+	}
+
+	serial := enumExprs(go2x3.StageOpts{})
+	ordered := enumExprs(go2x3.StageOpts{Workers: 4})
+	unordered := enumExprs(go2x3.StageOpts{Workers: 4, Unordered: true})
+
+	if strings.Join(serial, " ") != strings.Join(ordered, " ") {
+		t.Fatal("ordered parallel stage changed stream order")
+	}
+
+	sort.Strings(serial)
+	sort.Strings(unordered)
+	if strings.Join(serial, " ") != strings.Join(unordered, " ") {
+		t.Fatal("unordered parallel stage changed stream contents")
+	}
+}
+
+func TestTeeMergeConcat(t *testing.T) {
+	ctx := context.Background()
+
+	enum := func(Nv int) *go2x3.GraphStream {
+		stream, err := EnumPureParticles(ctx, EnumOpts{
+			VertexMin: Nv,
+			VertexMax: Nv,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return stream
+	}
+
+	N := enum(6).PullAll()
+	if N == 0 {
+		t.Fatal("no graphs")
+	}
+
+	// Tee both branches back into one
+	branches, err := enum(6).Tee(ctx, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count := go2x3.Merge(ctx, branches...).PullAll(); count != 2*N {
+		t.Fatalf("Tee+Merge: expected %d, got %d", 2*N, count)
+	}
+
+	// Cancelling one branch must not starve the other
+	branches, _ = enum(6).Tee(ctx, 2)
+	branches[0].Cancel()
+	if count := branches[1].PullAll(); count != N {
+		t.Fatalf("Tee with cancelled branch: expected %d, got %d", N, count)
+	}
+
+	// Concat preserves stream order
+	vtxCounts := func(stream *go2x3.GraphStream) (counts []byte) {
+		for X := range stream.All() {
+			counts = append(counts, byte(X.VertexCount()))
+		}
+		return counts
+	}
+	expect := append(vtxCounts(enum(5)), vtxCounts(enum(6))...)
+	if string(vtxCounts(go2x3.Concat(ctx, enum(5), enum(6)))) != string(expect) {
+		t.Fatal("Concat order mismatch")
+	}
+
+	src := enum(6)
+	if _, err := src.Tee(ctx, 0); !errors.Is(err, go2x3.ErrBadStreamParam) {
+		t.Fatalf("Tee(0): expected ErrBadStreamParam, got %v", err)
+	}
+	src.Cancel()
+
+	// Cancelling a stream stops a producer feeding it directly
+	seed := enum(3)
+	X := seed.PullGraph()
+	seed.Cancel()
+	defer X.Reclaim()
+	src = go2x3.NewGraphStream(ctx)
+	stopped := make(chan struct{})
+	go func() {
+		for src.PushGraph(X) {
+		}
+		src.Close()
+		close(stopped)
+	}()
+	merged := go2x3.Merge(ctx, src)
+	merged.PullGraph().Reclaim()
+	merged.Cancel()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Cancel did not stop the upstream producer")
+	}
+}
+
+func TestLimitSkipSampleDedupe(t *testing.T) {
+	ctx := context.Background()
+
+	enum := func() *go2x3.GraphStream {
+		stream, err := EnumPureParticles(ctx, EnumOpts{
+			VertexMax: 7,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return stream
+	}
+
+	N := enum().PullAll()
+
+	limited := enum()
+	if count := limited.Limit(ctx, 20).PullAll(); count != 20 {
+		t.Fatalf("Limit: expected 20, got %d", count)
+	}
+	select {
+	case <-limited.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("Limit did not stop upstream")
+	}
+
+	none := enum()
+	if count := none.Limit(ctx, 0).PullAll(); count != 0 {
+		t.Fatalf("Limit(0): expected 0, got %d", count)
+	}
+	select {
+	case <-none.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("Limit(0) did not stop upstream")
+	}
+
+	if count := enum().Skip(ctx, 20).PullAll(); count != N-20 {
+		t.Fatalf("Skip: expected %d, got %d", N-20, count)
+	}
+
+	sampleA := enum().Sample(ctx, 0.25, 77).PullAll()
+	sampleB := enum().Sample(ctx, 0.25, 77).PullAll()
+	if sampleA != sampleB || sampleA == 0 || sampleA >= N {
+		t.Fatalf("Sample: unexpected counts %d, %d of %d", sampleA, sampleB, N)
+	}
+	badRate := enum().Sample(ctx, 1.5, 77)
+	if count := badRate.PullAll(); count != 0 || !errors.Is(badRate.Err(), go2x3.ErrBadStreamParam) {
+		t.Fatalf("Sample(1.5): expected ErrBadStreamParam, got %d graphs, err %v", count, badRate.Err())
+	}
+
+	// The walker only emits graphs with unique traces, so deduping by traces is a no-op, but deduping by vertex count is not
+	if count := enum().DedupeBy(ctx, go2x3.KeyByTraces(0)).PullAll(); count != N {
+		t.Fatalf("DedupeBy traces: expected %d, got %d", N, count)
+	}
+	byVtxCount := func(X go2x3.State, key []byte) []byte {
+		return append(key, byte(X.VertexCount()))
+	}
+	if count := enum().DedupeBy(ctx, byVtxCount).PullAll(); count != 7 {
+		t.Fatalf("DedupeBy vtx count: expected 7, got %d", count)
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	ctx := context.Background()
+
+	stream, err := EnumPureParticles(ctx, EnumOpts{
+		VertexMax: 6,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	next, agg := stream.Aggregate(ctx, go2x3.AggregateOpts{
+		GroupBy:        []go2x3.AggregateField{go2x3.ByNumVertex},
+		DistinctTraces: true,
+	})
+	N := next.PullAll()
+	<-agg.Done()
+
+	rows := agg.Rows()
+	if len(rows) != 6 {
+		t.Fatalf("expected 6 rows, got %d", len(rows))
+	}
+	total := int64(0)
+	for i, row := range rows {
+		if row.Key[0] != int64(i+1) {
+			t.Fatal("rows not in order")
+		}
+		if row.DistinctTraces != row.Count {
+			t.Fatal("walker should only emit unique traces")
+		}
+		total += row.Count
+	}
+	if total != int64(N) {
+		t.Fatalf("expected total %d, got %d", N, total)
+	}
+
+	buf := strings.Builder{}
+	agg.WriteTable(&buf)
+	if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != 7 || !strings.Contains(lines[0], "verts") {
+		t.Fatalf("unexpected table:\n%s", buf.String())
+	}
+}
+
+func TestExactTraces(t *testing.T) {
+	stream, err := EnumPureParticles(context.Background(), EnumOpts{
+		VertexMax: 5,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	count, overflows := 0, 0
+	for X := range stream.All() {
+		exact := X.(go2x3.ExactTracesProvider)
+		TX, err := exact.CheckedTraces(16)
+		if err != nil {
+			t.Fatal(err)
+		}
+		TXbig, err := exact.BigTraces(16).Traces()
+		if err != nil || !TX.IsEqual(TXbig) {
+			t.Fatalf("BigTraces mismatch:\n  %v\n  %v", TX, TXbig)
+		}
+		if _, err = exact.CheckedTraces(64); errors.Is(err, go2x3.ErrTracesOverflow) {
+			overflows++
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		count++
+	}
+	if count == 0 || overflows == 0 {
+		t.Fatalf("got %d graphs, %d overflows", count, overflows)
+	}
+}
+
+func TestConstructionVtxState(t *testing.T) {
+	stream, err := EnumPureParticles(context.Background(), EnumOpts{
+		VertexMax: 6,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	count := 0
+	for X := range stream.All() {
+		Xs := X.(*Construction).VtxState()
+		if Xs.VertexCount() != X.VertexCount() || !Xs.Traces(0).IsEqual(X.Traces(0)) {
+			t.Fatalf("VtxState Traces %v, expected %v", Xs.Traces(0), X.Traces(0))
+		}
+		if _, err := X.MarshalOut(nil, go2x3.AsState); err != nil {
+			t.Fatal(err)
+		}
+		Xs.Reclaim()
+		count++
+	}
+	if count == 0 || stream.Err() != nil {
+		t.Fatalf("enumerated %d graphs (%v)", count, stream.Err())
+	}
+}
+
+func TestConstructionEncoding(t *testing.T) {
+	stream, err := EnumPureParticles(context.Background(), EnumOpts{
+		VertexMax: 6,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := map[string]bool{}
+	for X := range stream.All() {
+		for _, form := range []graph.EncodingForm{graph.EncodingForm_BinaryOpString, graph.EncodingForm_EnumerationID} {
+			enc, err := graph.EncodeGraph(nil, X, form)
+			if err != nil {
+				t.Fatalf("%v: %v", form, err)
+			}
+			str, err := graph.FormatGraph(X, form)
+			if err != nil {
+				t.Fatalf("%v: %v", form, err)
+			}
+			if graph.SniffEncodingForm(enc) != form || graph.SniffEncodingForm([]byte(str)) != form {
+				t.Fatalf("%v: sniffed as %v", form, graph.SniffEncodingForm(enc))
+			}
+			if form == graph.EncodingForm_EnumerationID {
+				if ids[str] {
+					t.Fatalf("duplicate EnumerationID %s", str)
+				}
+				ids[str] = true
+			}
+
+			for _, in := range [][]byte{enc, []byte(str)} {
+				Xd, err := graph.DecodeGraph(in, graph.EncodingForm_AutoDetect)
+				if err != nil {
+					t.Fatalf("%v: %v", form, err)
+				}
+				Xc := Xd.(*Construction)
+				if len(Xc.Ops) != len(X.(*Construction).Ops) || !Xc.Traces(0).IsEqual(X.Traces(0)) {
+					t.Fatalf("%v: decoded %v, expected %v", form, Xc.Traces(0), X.Traces(0))
+				}
+				Xd.Reclaim()
+			}
+		}
+	}
+	if len(ids) == 0 || stream.Err() != nil {
+		t.Fatalf("enumerated %d graphs (%v)", len(ids), stream.Err())
+	}
+
+	// EnumerationID requires a construction starting from a single sprouted vertex, and a failed encoding appends nothing
+	prefix := []byte("prefix")
+	for _, ops := range [][]GrowOp{
+		{{OpCode: OpCode_Sprout, Count: 2}},
+		{{OpCode: OpCode_AddEdge, Count: 1}},
+		{{OpCode: OpCode_Sprout, Count: 1, FromVtx: 1, FromSlot: 1}},
+		{{OpCode: OpCode_Sprout, Count: 1}, {OpCode: OpCode_Sprout, Count: 1, FromVtx: 1, FromSlot: 1}, {OpCode: OpCode_Sprout, Count: 2, FromVtx: 2, FromSlot: 1}},
+	} {
+		Xc := &Construction{Ops: ops}
+		if out, err := Xc.AppendEnumerationID(prefix); err != go2x3.ErrNotSupported || string(out) != "prefix" {
+			t.Fatalf("%v: expected ErrNotSupported and out unchanged, got %q (%v)", ops, out, err)
+		}
+		if out, err := graph.EncodeGraph(prefix, Xc, graph.EncodingForm_EnumerationID); err != go2x3.ErrNotSupported || string(out) != "prefix" {
+			t.Fatalf("%v: expected ErrNotSupported and out unchanged, got %q (%v)", ops, out, err)
+		}
+	}
+
+	for _, bad := range []string{"02", "0201", "020101000000", "0302", "0380", "03ff01"} {
+		if _, err := graph.DecodeGraph([]byte(bad), graph.EncodingForm_AutoDetect); err == nil {
+			t.Fatalf("%s: expected decode error", bad)
+		}
+	}
+}
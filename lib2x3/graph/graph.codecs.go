@@ -0,0 +1,171 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/hex"
+	"sync"
+
+	"github.com/fine-structures/fine.SDK/go2x3"
+)
+
+// A graph may be expressed in any EncodingForm, where a GraphCodec for each form is registered by the package that
+// implements it.  The form of a given encoding is identified by sniffing (see SniffEncodingForm):
+//
+//	GraphExpr           text, e.g. "1-2-3" or "mirror(1-2-3)"
+//	BinaryOpString      its EncodingForm byte followed by its payload, or that in hex (e.g. "02...")
+//	EnumerationID       same as BinaryOpString, e.g. "03..."
+//
+// Since a graph expression never starts with '0' (vertex IDs are one-based), the hex text forms are unambiguous.
+
+// GraphCodec encodes and decodes graphs of a particular EncodingForm.
+type GraphCodec interface {
+
+	// EncodingForm returns the form this codec encodes and decodes.
+	EncodingForm() EncodingForm
+
+	// AppendEncoding appends the encoding of X, returning go2x3.ErrNotSupported if X can't be expressed in this form.
+	// On failure, out is returned unchanged.
+	AppendEncoding(out []byte, X go2x3.State) ([]byte, error)
+
+	// Decode returns a new State from the given encoding (as appended by AppendEncoding).
+	Decode(enc []byte) (go2x3.State, error)
+}
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = map[EncodingForm]GraphCodec{}
+)
+
+func init() {
+	go2x3.RegisterGraphDecoder(func(enc []byte) (go2x3.State, error) {
+		return DecodeGraph(enc, EncodingForm_AutoDetect)
+	})
+}
+
+// autoDetectOrder is the order forms are tried in when encoding with EncodingForm_AutoDetect.
+var autoDetectOrder = []EncodingForm{
+	EncodingForm_GraphExpr,
+	EncodingForm_EnumerationID,
+	EncodingForm_BinaryOpString,
+}
+
+// RegisterCodec makes the given GraphCodec available for its EncodingForm, replacing any codec of the same form.
+func RegisterCodec(codec GraphCodec) {
+	codecsMu.Lock()
+	codecs[codec.EncodingForm()] = codec
+	codecsMu.Unlock()
+}
+
+// GetCodec returns the GraphCodec registered for the given EncodingForm.
+func GetCodec(form EncodingForm) (GraphCodec, error) {
+	codecsMu.RLock()
+	codec := codecs[form]
+	codecsMu.RUnlock()
+	if codec == nil {
+		return nil, go2x3.ErrNotSupported
+	}
+	return codec, nil
+}
+
+// SniffEncodingForm returns the EncodingForm of the given encoding, which may be in binary or text form.
+func SniffEncodingForm(enc []byte) EncodingForm {
+	if len(enc) > 0 && isBinaryForm(EncodingForm(enc[0])) {
+		return EncodingForm(enc[0])
+	}
+	if bin, ok := decodeHexForm(enc); ok {
+		return EncodingForm(bin[0])
+	}
+	return EncodingForm_GraphExpr
+}
+
+func isBinaryForm(form EncodingForm) bool {
+	return form == EncodingForm_BinaryOpString || form == EncodingForm_EnumerationID
+}
+
+// decodeHexForm returns the given hex text as binary if it is a binary form in hex.
+func decodeHexForm(enc []byte) ([]byte, bool) {
+	enc = bytes.TrimSpace(enc)
+	if len(enc) < 2 || enc[0] != '0' {
+		return nil, false
+	}
+	bin := make([]byte, hex.DecodedLen(len(enc)))
+	if _, err := hex.Decode(bin, enc); err != nil || !isBinaryForm(EncodingForm(bin[0])) {
+		return nil, false
+	}
+	return bin, true
+}
+
+// EncodeGraph appends the encoding of X in the given EncodingForm, where binary forms are appended as binary.
+// EncodingForm_AutoDetect uses the first form able to express X, preferring GraphExpr.
+// On failure, out is returned unchanged.
+func EncodeGraph(out []byte, X go2x3.State, form EncodingForm) ([]byte, error) {
+	if X == nil {
+		return out, go2x3.ErrNilGraph
+	}
+	if form != EncodingForm_AutoDetect {
+		codec, err := GetCodec(form)
+		if err != nil {
+			return out, err
+		}
+		enc, err := codec.AppendEncoding(out, X)
+		if err != nil {
+			return out, err
+		}
+		return enc, nil
+	}
+
+	// Each form appends to out as given, discarding whatever a failed form appended before failing.
+	for _, form := range autoDetectOrder {
+		if enc, err := EncodeGraph(out, X, form); err == nil {
+			return enc, nil
+		}
+	}
+	return out, go2x3.ErrNotSupported
+}
+
+// FormatGraph returns the text encoding of X in the given EncodingForm, where binary forms are written in hex.
+func FormatGraph(X go2x3.State, form EncodingForm) (string, error) {
+	enc, err := EncodeGraph(nil, X, form)
+	if err != nil {
+		return "", err
+	}
+	if len(enc) > 0 && isBinaryForm(EncodingForm(enc[0])) {
+		return hex.EncodeToString(enc), nil
+	}
+	return string(enc), nil
+}
+
+// DecodeGraph returns a new State decoded from the given binary or text encoding.
+// If form is EncodingForm_AutoDetect, the form is sniffed (see SniffEncodingForm).
+func DecodeGraph(enc []byte, form EncodingForm) (go2x3.State, error) {
+	if form == EncodingForm_AutoDetect {
+		form = SniffEncodingForm(enc)
+	}
+	codec, err := GetCodec(form)
+	if err != nil {
+		return nil, err
+	}
+	if isBinaryForm(form) && (len(enc) == 0 || EncodingForm(enc[0]) != form) {
+		bin, ok := decodeHexForm(enc)
+		if !ok || EncodingForm(bin[0]) != form {
+			return nil, go2x3.ErrBadEncoding
+		}
+		enc = bin
+	}
+	return codec.Decode(enc)
+}
+
+// ParseEncodingForm returns the EncodingForm having the given name (e.g. "EnumerationID" or "EncodingForm_EnumerationID"),
+// where "" denotes EncodingForm_AutoDetect.
+func ParseEncodingForm(name string) (EncodingForm, error) {
+	if name == "" {
+		return EncodingForm_AutoDetect, nil
+	}
+	if form, ok := EncodingForm_value[name]; ok {
+		return EncodingForm(form), nil
+	}
+	if form, ok := EncodingForm_value["EncodingForm_"+name]; ok {
+		return EncodingForm(form), nil
+	}
+	return EncodingForm_AutoDetect, go2x3.ErrNotSupported
+}
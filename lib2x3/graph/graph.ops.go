@@ -0,0 +1,163 @@
+package graph
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/alecthomas/participle/v2"
+	"github.com/fine-structures/fine.SDK/go2x3"
+)
+
+// An op expression is the text form of an OpNode tree, where each op is written as a call over its components:
+//
+//	sum(p+, e-)           OpCode_Sum of each component
+//	power(4, 1=2)         OpCode_Power with Repeat=4
+//	power(4:2, 1=2)       OpCode_Power with Repeat=4 and VtxID=2
+//	mirror(1-2-3)         OpCode_Mirror
+//	replace(3, tetra)     OpCode_Replace with VtxID=3
+//
+// A component is either another op, a single run graph expression (e.g. 1-2=3^), a quoted graph expression
+// (e.g. "1-2-3-1,1-4"), or a graph name (e.g. tetra or p+), each of which becomes an OpCode_Nil node having that GraphExpr.
+// Op names are reserved (see OpNames), so a graph name is any other identifier, optionally followed by + or - (see CheckGraphName).
+
+type opTerm struct {
+	Sum     *opArgs    `parser:"  'sum' '(' @@ ')'"`
+	Mirror  *opArgs    `parser:"| 'mirror' '(' @@ ')'"`
+	Power   *opPower   `parser:"| 'power' '(' @@ ')'"`
+	Replace *opReplace `parser:"| 'replace' '(' @@ ')'"`
+	Quoted  string     `parser:"| @String"`
+	Name    string     `parser:"| @Ident @( '+' | '-' )?"`
+	Expr    string     `parser:"| @Int @( Int | '-' | '~' | '=' | '^' )*"`
+}
+
+type opArgs struct {
+	Terms []*opTerm `parser:"@@ ( ',' @@ )*"`
+}
+
+type opPower struct {
+	Repeat int64  `parser:"@Int"`
+	VtxID  int64  `parser:"( ':' @Int )? ','"`
+	Args   opArgs `parser:"@@"`
+}
+
+type opReplace struct {
+	VtxID int64  `parser:"@Int ','"`
+	Args  opArgs `parser:"@@"`
+}
+
+var parseOpExpr = participle.MustBuild[opTerm](participle.Unquote("String"))
+
+// OpNames are the ops of an op expression, which are reserved and so can't name a graph.
+var OpNames = []string{"sum", "mirror", "power", "replace"}
+
+// CheckGraphName returns go2x3.ErrBadOpNode unless name can name a graph in an op expression: an identifier, optionally
+// followed by + or -, that is not one of OpNames.  Since an identifier can't begin with a digit, a graph name is never
+// mistaken for a graph expression (e.g. 1-2-3).
+func CheckGraphName(name string) error {
+	if slices.Contains(OpNames, name) {
+		return fmt.Errorf("%w: %q is reserved for an op", go2x3.ErrBadOpNode, name)
+	}
+	if term, err := parseOpExpr.ParseString("", name); err != nil || term.Name != name {
+		return fmt.Errorf("%w: %q is not a valid graph name", go2x3.ErrBadOpNode, name)
+	}
+	return nil
+}
+
+// ParseOpExpr parses the given op expression (e.g. "mirror(1-2-3)") into an OpNode tree.
+func ParseOpExpr(opExpr string) (*OpNode, error) {
+	term, err := parseOpExpr.ParseString("", opExpr)
+	if err != nil {
+		return nil, err
+	}
+	return term.opNode(), nil
+}
+
+func (term *opTerm) opNode() *OpNode {
+	switch {
+	case term.Sum != nil:
+		return term.Sum.opNode(OpCode_Sum)
+	case term.Mirror != nil:
+		return term.Mirror.opNode(OpCode_Mirror)
+	case term.Power != nil:
+		op := term.Power.Args.opNode(OpCode_Power)
+		op.Repeat = term.Power.Repeat
+		op.VtxID = term.Power.VtxID
+		return op
+	case term.Replace != nil:
+		op := term.Replace.Args.opNode(OpCode_Replace)
+		op.VtxID = term.Replace.VtxID
+		return op
+	case term.Quoted != "":
+		return &OpNode{GraphExpr: term.Quoted}
+	case term.Name != "":
+		return &OpNode{GraphExpr: term.Name}
+	default:
+		return &OpNode{GraphExpr: term.Expr}
+	}
+}
+
+func (args *opArgs) opNode(opCode OpCode) *OpNode {
+	op := &OpNode{
+		OpCode:     opCode,
+		Components: make([]*OpNode, len(args.Terms)),
+	}
+	for i, term := range args.Terms {
+		op.Components[i] = term.opNode()
+	}
+	return op
+}
+
+// AppendOpExpr appends the op expression of this OpNode tree (see ParseOpExpr), so that parsing it returns an equivalent tree.
+// An OpCode_Nil node having components is written as a sum.
+func (op *OpNode) AppendOpExpr(out []byte) []byte {
+	if op.OpCode == OpCode_Nil && len(op.Components) == 0 {
+		return appendGraphExprTerm(out, op.GraphExpr)
+	}
+
+	switch op.OpCode {
+	case OpCode_Nil, OpCode_Sum:
+		out = append(out, "sum("...)
+	case OpCode_Mirror:
+		out = append(out, "mirror("...)
+	case OpCode_Power:
+		out = append(out, "power("...)
+		out = strconv.AppendInt(out, op.Repeat, 10)
+		if op.VtxID != 0 {
+			out = append(out, ':')
+			out = strconv.AppendInt(out, op.VtxID, 10)
+		}
+		out = append(out, ", "...)
+	case OpCode_Replace:
+		out = append(out, "replace("...)
+		out = strconv.AppendInt(out, op.VtxID, 10)
+		out = append(out, ", "...)
+	default:
+		out = append(out, op.OpCode.String()...)
+		out = append(out, '(')
+	}
+
+	sep := false
+	if op.GraphExpr != "" {
+		out = appendGraphExprTerm(out, op.GraphExpr)
+		sep = true
+	}
+	for _, sub := range op.Components {
+		if sep {
+			out = append(out, ", "...)
+		}
+		out = sub.AppendOpExpr(out)
+		sep = true
+	}
+	return append(out, ')')
+}
+
+// appendGraphExprTerm appends the given graph expression as a component, quoting it unless it parses back as is.
+func appendGraphExprTerm(out []byte, graphExpr string) []byte {
+	if term, err := parseOpExpr.ParseString("", graphExpr); err == nil {
+		if term.Name == graphExpr || term.Expr == graphExpr {
+			return append(out, graphExpr...)
+		}
+	}
+	return strconv.AppendQuote(out, graphExpr)
+}
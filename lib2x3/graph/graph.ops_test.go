@@ -0,0 +1,52 @@
+package graph
+
+import (
+	"testing"
+)
+
+func TestParseOpExpr(t *testing.T) {
+	tests := []struct {
+		opExpr  string
+		printed string // "" denotes opExpr
+	}{
+		{"sum(p+, e-)", ""},
+		{"mirror(1-2-3-1)", ""},
+		{"replace(1, tetra)", ""},
+		{`replace(1, "1-2-3-1,1-4,2-4,3-4")`, ""},
+		{"power(4:2, 1=2)", ""},
+		{"mirror(power(4:1, 1))", ""},
+		{"sum(1^-2~3, power(2, gamma))", ""},
+		{`sum( "1-2", 1 - 2 , "p+" )`, "sum(1-2, 1-2, p+)"},
+		{`"1-2"`, "1-2"},
+	}
+	for _, test := range tests {
+		op, err := ParseOpExpr(test.opExpr)
+		if err != nil {
+			t.Fatalf("%s: %v", test.opExpr, err)
+		}
+		expect := test.printed
+		if expect == "" {
+			expect = test.opExpr
+		}
+		if printed := string(op.AppendOpExpr(nil)); printed != expect {
+			t.Fatalf("%s: printed as %s, expected %s", test.opExpr, printed, expect)
+		}
+	}
+
+	for _, bad := range []string{"mirror(", "sum()", "power(1-2)", "replace(tetra)", "foo(1)"} {
+		if _, err := ParseOpExpr(bad); err == nil {
+			t.Fatalf("%s: expected error", bad)
+		}
+	}
+
+	for _, name := range []string{"tetra", "p+", "e-", "μ-", "Sum", "n0"} {
+		if err := CheckGraphName(name); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+	}
+	for _, bad := range []string{"sum", "mirror", "power", "replace", "3x", "1-2", "p +", "a,b", ""} {
+		if err := CheckGraphName(bad); err == nil {
+			t.Fatalf("%q: expected a bad graph name", bad)
+		}
+	}
+}
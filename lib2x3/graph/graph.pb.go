@@ -209,6 +209,8 @@ type OpNode struct {
 	VtxID      int64     `protobuf:"varint,2,opt,name=VtxID,proto3" json:"VtxID,omitempty"`
 	Repeat     int64     `protobuf:"varint,3,opt,name=Repeat,proto3" json:"Repeat,omitempty"`
 	Components []*OpNode `protobuf:"bytes,4,rep,name=Components,proto3" json:"Components,omitempty"`
+	// If set, the graph expression (e.g. "1-2-3") this node evaluates to, summed with any components.
+	GraphExpr string `protobuf:"bytes,5,opt,name=GraphExpr,proto3" json:"GraphExpr,omitempty"`
 }
 
 func (m *OpNode) Reset()      { *m = OpNode{} }
@@ -271,6 +273,13 @@ func (m *OpNode) GetComponents() []*OpNode {
 	return nil
 }
 
+func (m *OpNode) GetGraphExpr() string {
+	if m != nil {
+		return m.GraphExpr
+	}
+	return ""
+}
+
 // GraphDef is a particular vertex+edge assignment with bound names.
 // Has similarities to a "Contributing structure" in quantum|chemistry lingo. https://en.wikipedia.org/wiki/Resonance_(chemistry)
 type GraphDef struct {
@@ -615,58 +624,58 @@ func init() {
 func init() { proto.RegisterFile("lib2x3/graph/graph.proto", fileDescriptor_23d0cecb80079ca5) }
 
 var fileDescriptor_23d0cecb80079ca5 = []byte{
-	// 806 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x64, 0x54, 0x4d, 0x8f, 0xdb, 0x44,
-	0x18, 0xf6, 0xd8, 0xd9, 0x7c, 0xbc, 0xf9, 0xe8, 0x74, 0xba, 0x6d, 0xcd, 0x16, 0x19, 0x2b, 0x6a,
-	0xa5, 0x28, 0x12, 0x45, 0x9b, 0x22, 0x71, 0x66, 0x93, 0x65, 0x15, 0x09, 0xb2, 0x95, 0x53, 0x52,
-	0xc1, 0x25, 0x72, 0xed, 0x69, 0xb0, 0x94, 0xcc, 0x58, 0xe3, 0x71, 0x48, 0x39, 0x71, 0xe4, 0x00,
-	0x12, 0x3f, 0x83, 0x1f, 0xc1, 0x0f, 0xe0, 0xb8, 0xc7, 0x72, 0x63, 0xb3, 0x17, 0x8e, 0xfd, 0x09,
-	0x68, 0xc6, 0x1f, 0xeb, 0x2c, 0x97, 0xe4, 0x7d, 0x9e, 0xe7, 0x9d, 0x77, 0xde, 0x8f, 0x79, 0x0d,
-	0xf6, 0x3a, 0x7a, 0x33, 0xda, 0xbd, 0xf8, 0x6c, 0x25, 0xfc, 0xf8, 0x87, 0xec, 0xf7, 0x79, 0x2c,
-	0xb8, 0xe4, 0xe4, 0x48, 0x83, 0xfe, 0xaf, 0x08, 0xea, 0x97, 0xf1, 0x8c, 0x87, 0x94, 0x3c, 0x53,
-	0xd6, 0x98, 0x87, 0xd4, 0x46, 0x2e, 0x1a, 0xf4, 0x46, 0xdd, 0xe7, 0x99, 0x7f, 0x46, 0x7a, 0xb9,
-	0x48, 0x8e, 0xe1, 0x68, 0x21, 0x77, 0xd3, 0x89, 0x6d, 0xba, 0x68, 0x60, 0x79, 0x19, 0x20, 0x8f,
-	0xa0, 0xee, 0xd1, 0x98, 0xfa, 0xd2, 0xb6, 0x34, 0x9d, 0x23, 0xf2, 0x29, 0xc0, 0x98, 0x6f, 0x62,
-	0xce, 0x28, 0x93, 0x89, 0x5d, 0x73, 0xad, 0x41, 0xbb, 0x12, 0x58, 0xdd, 0xeb, 0x55, 0x1c, 0xfa,
-	0x7f, 0x23, 0x68, 0x5e, 0x28, 0x71, 0x42, 0xdf, 0x92, 0x67, 0xd0, 0x98, 0x26, 0x2f, 0x45, 0xb4,
-	0x29, 0x32, 0x6a, 0xe7, 0x07, 0xcf, 0x38, 0x5f, 0x7b, 0x85, 0x46, 0x4e, 0xa0, 0xf9, 0x4a, 0xf8,
-	0x01, 0x4d, 0xf2, 0x9c, 0x6a, 0x5e, 0x89, 0x89, 0x0d, 0x0d, 0x1d, 0x6e, 0x3a, 0xd1, 0x79, 0xd5,
-	0xbc, 0x02, 0x92, 0xa7, 0xd0, 0xd5, 0xe6, 0x39, 0x0b, 0x78, 0x18, 0xb1, 0x95, 0xdd, 0x74, 0xd1,
-	0xa0, 0xe3, 0x1d, 0x92, 0x64, 0x08, 0x78, 0xec, 0x33, 0xce, 0xa2, 0x20, 0xe3, 0x77, 0xb1, 0xb0,
-	0x5b, 0x2e, 0x1a, 0xb4, 0xbc, 0xff, 0xf1, 0xc4, 0x01, 0x28, 0x41, 0x62, 0x77, 0x5c, 0x6b, 0xd0,
-	0xf2, 0x2a, 0x4c, 0xff, 0x35, 0x34, 0x16, 0x72, 0x77, 0x1e, 0xae, 0x74, 0xca, 0x93, 0x44, 0xde,
-	0xb6, 0xb1, 0xeb, 0x95, 0x58, 0x69, 0x73, 0x11, 0x64, 0x9a, 0x95, 0x69, 0x05, 0x56, 0xbd, 0x1f,
-	0xf3, 0x94, 0x49, 0xbb, 0x9e, 0xf5, 0x5e, 0x83, 0xfe, 0x9f, 0x08, 0x9a, 0x0b, 0xb9, 0xbb, 0x10,
-	0x3c, 0x8d, 0xb3, 0x8a, 0x79, 0x1a, 0x97, 0x91, 0x0b, 0x78, 0xb7, 0x17, 0xdd, 0xdb, 0x5e, 0x94,
-	0x61, 0x8f, 0x2a, 0x61, 0xc9, 0x00, 0x1a, 0x97, 0x61, 0x38, 0x8f, 0x56, 0x4c, 0xf7, 0xa6, 0x37,
-	0xea, 0x15, 0x73, 0xcb, 0x58, 0xaf, 0x90, 0xc9, 0x53, 0x38, 0x52, 0x65, 0x25, 0xf6, 0x3d, 0x3d,
-	0xdf, 0xc2, 0x2f, 0xaf, 0xd6, 0xcb, 0x44, 0xf5, 0x44, 0xc6, 0xef, 0x82, 0x35, 0x4d, 0x6c, 0xec,
-	0x5a, 0xea, 0x89, 0x64, 0xa8, 0xff, 0x1b, 0x82, 0x96, 0xce, 0xe4, 0x15, 0x15, 0x1b, 0xe2, 0x42,
-	0x7b, 0x41, 0x85, 0xa4, 0xbb, 0x2c, 0x23, 0xa4, 0x33, 0xad, 0x52, 0xca, 0x63, 0x2e, 0x45, 0x1a,
-	0xc8, 0x54, 0xd0, 0x72, 0xe4, 0x55, 0x8a, 0x7c, 0x0c, 0xad, 0x85, 0x2f, 0x22, 0x9f, 0xc9, 0x72,
-	0xee, 0xb7, 0x04, 0xe9, 0x81, 0x39, 0x3e, 0xb5, 0xdb, 0xba, 0x54, 0x73, 0x7c, 0xaa, 0xf1, 0xc8,
-	0xee, 0xe4, 0x78, 0x34, 0xf4, 0x8b, 0x3d, 0x20, 0x3d, 0x80, 0xcc, 0x5a, 0xce, 0xa2, 0x35, 0x36,
-	0x2a, 0x78, 0x9e, 0x6e, 0x30, 0x22, 0x18, 0x3a, 0x39, 0x7e, 0xc9, 0x7f, 0xa4, 0x02, 0x9b, 0xe4,
-	0x3e, 0x74, 0x73, 0xe6, 0x9b, 0x48, 0x08, 0x2e, 0xb0, 0x45, 0x08, 0xf4, 0x72, 0xca, 0xa3, 0xf1,
-	0xda, 0x0f, 0x28, 0xae, 0x0d, 0xbf, 0x80, 0x9a, 0x7a, 0xc3, 0xe4, 0x18, 0xb0, 0xfa, 0x5f, 0x7e,
-	0xcb, 0x92, 0x98, 0x06, 0xd1, 0xdb, 0x88, 0x86, 0xd8, 0x20, 0x1d, 0x68, 0x6a, 0xf6, 0x3b, 0x9a,
-	0x60, 0x44, 0xda, 0xd0, 0xd0, 0x68, 0xc6, 0xb1, 0x35, 0x7c, 0x5d, 0x4e, 0x57, 0x5d, 0x9e, 0x9b,
-	0xcb, 0x2d, 0x8f, 0xd4, 0xb9, 0x07, 0x70, 0xaf, 0x60, 0xbe, 0xe6, 0x3c, 0x5e, 0xc8, 0x1d, 0x46,
-	0xe4, 0x21, 0xdc, 0xaf, 0x92, 0xda, 0xc6, 0xa6, 0x2a, 0xa5, 0xa0, 0x2f, 0x4e, 0xb1, 0x35, 0xdc,
-	0x42, 0x5b, 0xcf, 0x60, 0x2e, 0x7d, 0x99, 0x26, 0xe4, 0x31, 0x3c, 0xa8, 0xc0, 0xe5, 0x94, 0x6d,
-	0xfd, 0xb5, 0xbe, 0xe3, 0x23, 0x78, 0x58, 0x15, 0x16, 0x8a, 0xf6, 0x25, 0x0d, 0xb1, 0x49, 0x6c,
-	0x38, 0xae, 0x4a, 0x6a, 0xab, 0x53, 0xa5, 0xd4, 0xee, 0x1e, 0xca, 0x36, 0xe7, 0x27, 0x1a, 0xe2,
-	0xfa, 0x70, 0x52, 0x3e, 0x32, 0x95, 0x7e, 0x6e, 0x2e, 0x67, 0xbe, 0x4c, 0x85, 0xaf, 0x5a, 0xae,
-	0x5a, 0x9c, 0x93, 0xdf, 0x53, 0xc1, 0x31, 0xd2, 0xfd, 0xcc, 0x99, 0x29, 0xdb, 0x52, 0x21, 0xb1,
-	0x39, 0xfc, 0x05, 0x41, 0xa7, 0xd8, 0xd9, 0xaf, 0xb8, 0xd8, 0x90, 0x27, 0xf0, 0xb8, 0x8a, 0x97,
-	0x5f, 0xa6, 0x92, 0x4f, 0xa8, 0xa4, 0x81, 0xc4, 0x06, 0x39, 0x81, 0x47, 0x07, 0x62, 0xb9, 0xa3,
-	0x18, 0x91, 0x4f, 0xe0, 0xc9, 0x81, 0x76, 0x16, 0x31, 0x5f, 0xbc, 0xbb, 0x8c, 0xe7, 0x52, 0x44,
-	0x6c, 0x85, 0x4d, 0xe2, 0xc0, 0xc9, 0x81, 0xc3, 0x39, 0x4b, 0x37, 0x54, 0xf8, 0x32, 0xe2, 0x6c,
-	0x3a, 0xc1, 0xd6, 0xd9, 0xe7, 0x57, 0xd7, 0x8e, 0xf1, 0xfe, 0xda, 0x31, 0x3e, 0x5c, 0x3b, 0xe8,
-	0xe7, 0xbd, 0x83, 0xfe, 0xd8, 0x3b, 0xe8, 0xaf, 0xbd, 0x83, 0xae, 0xf6, 0x0e, 0xfa, 0x67, 0xef,
-	0xa0, 0x7f, 0xf7, 0x8e, 0xf1, 0x61, 0xef, 0xa0, 0xdf, 0x6f, 0x1c, 0xe3, 0xea, 0xc6, 0x31, 0xde,
-	0xdf, 0x38, 0xc6, 0x9b, 0xba, 0xfe, 0x28, 0xbf, 0xf8, 0x2f, 0x00, 0x00, 0xff, 0xff, 0x3d, 0x47,
-	0xbf, 0x87, 0xb0, 0x05, 0x00, 0x00,
+	// 810 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x64, 0x54, 0xcb, 0x6e, 0xdb, 0x46,
+	0x14, 0xe5, 0x90, 0x7a, 0xf9, 0xea, 0x91, 0xc9, 0xc4, 0x49, 0x58, 0xa7, 0x60, 0x09, 0x21, 0x01,
+	0x04, 0x01, 0x4d, 0x61, 0xa5, 0x40, 0xd7, 0xb5, 0xe4, 0x1a, 0x02, 0x5a, 0x3b, 0xa0, 0x52, 0x05,
+	0xed, 0x46, 0x60, 0xc8, 0x89, 0x4a, 0x40, 0x9a, 0x21, 0x86, 0x43, 0x55, 0xe9, 0xaa, 0xcb, 0x6e,
+	0x0a, 0xf4, 0x33, 0x8a, 0x7e, 0x43, 0x3f, 0xa0, 0x4b, 0x2f, 0xd3, 0x5d, 0x2d, 0x6f, 0xba, 0xcc,
+	0x27, 0x14, 0x33, 0x7c, 0x88, 0x72, 0x37, 0xf6, 0x3d, 0xe7, 0x5c, 0xde, 0xb9, 0x4f, 0x81, 0xbd,
+	0x8a, 0xde, 0x8c, 0xb6, 0x2f, 0x3e, 0x5b, 0x0a, 0x3f, 0xfe, 0x21, 0xfb, 0xfb, 0x3c, 0x16, 0x5c,
+	0x72, 0x52, 0xd7, 0xa0, 0xff, 0x07, 0x82, 0xc6, 0x55, 0x7c, 0xc9, 0x43, 0x4a, 0x9e, 0x29, 0x6b,
+	0xcc, 0x43, 0x6a, 0x23, 0x17, 0x0d, 0x7a, 0xa3, 0xee, 0xf3, 0xcc, 0x3f, 0x23, 0xbd, 0x5c, 0x24,
+	0xc7, 0x50, 0x9f, 0xcb, 0xed, 0x74, 0x62, 0x9b, 0x2e, 0x1a, 0x58, 0x5e, 0x06, 0xc8, 0x23, 0x68,
+	0x78, 0x34, 0xa6, 0xbe, 0xb4, 0x2d, 0x4d, 0xe7, 0x88, 0x7c, 0x0a, 0x30, 0xe6, 0xeb, 0x98, 0x33,
+	0xca, 0x64, 0x62, 0xd7, 0x5c, 0x6b, 0xd0, 0xae, 0x04, 0x56, 0xef, 0x7a, 0x15, 0x07, 0xf2, 0x31,
+	0x1c, 0x5d, 0x28, 0xed, 0x7c, 0x1b, 0x0b, 0xbb, 0xee, 0xa2, 0xc1, 0x91, 0xb7, 0x27, 0xfa, 0x7f,
+	0x23, 0x68, 0x69, 0x34, 0xa1, 0x6f, 0xc9, 0x33, 0x68, 0x4e, 0x93, 0x97, 0x22, 0x5a, 0x17, 0xf9,
+	0xb6, 0xf3, 0xb0, 0x67, 0x9c, 0xaf, 0xbc, 0x42, 0x23, 0x27, 0xd0, 0x7a, 0x25, 0xfc, 0x80, 0x26,
+	0x79, 0xc6, 0x35, 0xaf, 0xc4, 0xc4, 0x86, 0xa6, 0x0e, 0x37, 0x9d, 0xe8, 0xac, 0x6b, 0x5e, 0x01,
+	0xc9, 0x53, 0xe8, 0x66, 0xcf, 0xb2, 0x80, 0x87, 0x11, 0x5b, 0xda, 0x2d, 0x17, 0x0d, 0x3a, 0xde,
+	0x21, 0x49, 0x86, 0x80, 0xc7, 0x3e, 0xe3, 0x2c, 0x0a, 0xf6, 0x49, 0x1f, 0xe9, 0xa4, 0xff, 0xc7,
+	0x13, 0x07, 0xa0, 0x04, 0x89, 0xdd, 0x71, 0xad, 0xc1, 0x91, 0x57, 0x61, 0xfa, 0xaf, 0xa1, 0x39,
+	0x97, 0xdb, 0xf3, 0x70, 0xa9, 0x53, 0x9e, 0x24, 0x72, 0xdf, 0xe4, 0xae, 0x57, 0x62, 0xa5, 0xcd,
+	0x44, 0x90, 0x69, 0x56, 0xa6, 0x15, 0x58, 0x4d, 0x66, 0xcc, 0x53, 0x26, 0xed, 0x46, 0x36, 0x19,
+	0x0d, 0xfa, 0x7f, 0x22, 0x68, 0xcd, 0xe5, 0xf6, 0x42, 0xf0, 0x34, 0xce, 0x2a, 0xe6, 0x69, 0x5c,
+	0x46, 0x2e, 0xe0, 0xdd, 0x5e, 0x74, 0xf7, 0xbd, 0x28, 0xc3, 0xd6, 0x2b, 0x61, 0xc9, 0x00, 0x9a,
+	0x57, 0x61, 0x38, 0x8b, 0x96, 0x4c, 0xf7, 0xa6, 0x37, 0xea, 0x15, 0x53, 0xcd, 0x58, 0xaf, 0x90,
+	0xc9, 0x53, 0xa8, 0xab, 0xb2, 0x12, 0xfb, 0x9e, 0x9e, 0x7e, 0xe1, 0x97, 0x57, 0xeb, 0x65, 0xa2,
+	0x5a, 0xa0, 0xf1, 0xbb, 0x60, 0x45, 0x13, 0x1b, 0xbb, 0x96, 0x5a, 0xa0, 0x0c, 0xf5, 0x7f, 0x45,
+	0xf9, 0x4a, 0xbc, 0xa2, 0x62, 0x4d, 0x5c, 0x68, 0xcf, 0xa9, 0x90, 0x74, 0x9b, 0x65, 0x84, 0x74,
+	0xa6, 0x55, 0x4a, 0x79, 0xcc, 0xa4, 0x48, 0x03, 0x99, 0x0a, 0x5a, 0x8e, 0xbc, 0x4a, 0xa9, 0x1d,
+	0x9b, 0xfb, 0x22, 0xf2, 0x99, 0x2c, 0xe7, 0xbe, 0x27, 0x48, 0x0f, 0xcc, 0xf1, 0xa9, 0xdd, 0xd6,
+	0xa5, 0x9a, 0xe3, 0x53, 0x8d, 0x47, 0x76, 0x27, 0xc7, 0xa3, 0xa1, 0x5f, 0x5c, 0x09, 0xe9, 0x01,
+	0x64, 0xd6, 0xe2, 0x32, 0x5a, 0x61, 0xa3, 0x82, 0x67, 0xe9, 0x1a, 0x23, 0x82, 0xa1, 0x93, 0xe3,
+	0x97, 0xfc, 0x47, 0x2a, 0xb0, 0x49, 0xee, 0x43, 0x37, 0x67, 0xbe, 0x89, 0x84, 0xe0, 0x02, 0x5b,
+	0x84, 0x40, 0x2f, 0xa7, 0x3c, 0x1a, 0xaf, 0xfc, 0x80, 0xe2, 0xda, 0xf0, 0x0b, 0xa8, 0xa9, 0x1d,
+	0x26, 0xc7, 0x80, 0xd5, 0xff, 0xc5, 0xb7, 0x2c, 0x89, 0x69, 0x10, 0xbd, 0x8d, 0x68, 0x88, 0x0d,
+	0xd2, 0x81, 0x96, 0x66, 0xbf, 0xa3, 0x09, 0x46, 0xa4, 0x0d, 0x4d, 0x8d, 0x2e, 0x39, 0xb6, 0x86,
+	0xaf, 0xcb, 0xe9, 0xaa, 0xc7, 0x73, 0x73, 0xb1, 0xe1, 0x91, 0xfa, 0xee, 0x01, 0xdc, 0x2b, 0x98,
+	0xaf, 0x39, 0x8f, 0xe7, 0x72, 0x8b, 0x11, 0x79, 0x08, 0xf7, 0xab, 0xa4, 0xb6, 0xb1, 0xa9, 0x4a,
+	0x29, 0xe8, 0x8b, 0x53, 0x6c, 0x0d, 0x37, 0xd0, 0xd6, 0x33, 0x98, 0x49, 0x5f, 0xa6, 0x09, 0x79,
+	0x0c, 0x0f, 0x2a, 0x70, 0x31, 0x65, 0x1b, 0x7f, 0xa5, 0xdf, 0xf8, 0x08, 0x1e, 0x56, 0x85, 0xb9,
+	0xa2, 0x7d, 0x49, 0x43, 0x6c, 0x12, 0x1b, 0x8e, 0xab, 0x92, 0xba, 0xf9, 0x54, 0x29, 0xb5, 0xbb,
+	0x1f, 0x65, 0x97, 0xf3, 0x13, 0x0d, 0x71, 0x63, 0x38, 0x29, 0x97, 0x4c, 0xa5, 0x9f, 0x9b, 0x8b,
+	0x4b, 0x5f, 0xa6, 0xc2, 0x57, 0x2d, 0x57, 0x2d, 0xce, 0xc9, 0xef, 0xa9, 0xe0, 0x18, 0xe9, 0x7e,
+	0xe6, 0xcc, 0x94, 0x6d, 0xa8, 0x90, 0xd8, 0x1c, 0xfe, 0x82, 0xa0, 0x53, 0xdc, 0xec, 0x57, 0x5c,
+	0xac, 0xc9, 0x13, 0x78, 0x5c, 0xc5, 0x8b, 0x2f, 0x53, 0xc9, 0x27, 0x54, 0xd2, 0x40, 0x62, 0x83,
+	0x9c, 0xc0, 0xa3, 0x03, 0xb1, 0xbc, 0x51, 0x8c, 0xc8, 0x27, 0xf0, 0xe4, 0x40, 0x3b, 0x8b, 0x98,
+	0x2f, 0xde, 0x5d, 0xc5, 0x33, 0x29, 0x22, 0xb6, 0xc4, 0x26, 0x71, 0xe0, 0xe4, 0xc0, 0xe1, 0x9c,
+	0xa5, 0x6b, 0x2a, 0x7c, 0x19, 0x71, 0x36, 0x9d, 0x60, 0xeb, 0xec, 0xf3, 0xeb, 0x1b, 0xc7, 0x78,
+	0x7f, 0xe3, 0x18, 0x1f, 0x6e, 0x1c, 0xf4, 0xf3, 0xce, 0x41, 0xbf, 0xef, 0x1c, 0xf4, 0xd7, 0xce,
+	0x41, 0xd7, 0x3b, 0x07, 0xfd, 0xb3, 0x73, 0xd0, 0xbf, 0x3b, 0xc7, 0xf8, 0xb0, 0x73, 0xd0, 0x6f,
+	0xb7, 0x8e, 0x71, 0x7d, 0xeb, 0x18, 0xef, 0x6f, 0x1d, 0xe3, 0x4d, 0x43, 0xff, 0x64, 0xbf, 0xf8,
+	0x6f, 0x00, 0x86, 0xb5, 0xc0, 0xa1, 0xce, 0x05, 0x00, 0x00,
 }
 
 func (x OpCode) String() string {
@@ -747,6 +756,9 @@ func (this *OpNode) Equal(that interface{}) bool {
 			return false
 		}
 	}
+	if this.GraphExpr != that1.GraphExpr {
+		return false
+	}
 	return true
 }
 func (this *GraphDef) Equal(that interface{}) bool {
@@ -912,7 +924,7 @@ func (this *OpNode) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 8)
+	s := make([]string, 0, 9)
 	s = append(s, "&graph.OpNode{")
 	s = append(s, "OpCode: "+fmt.Sprintf("%#v", this.OpCode)+",\n")
 	s = append(s, "VtxID: "+fmt.Sprintf("%#v", this.VtxID)+",\n")
@@ -920,6 +932,7 @@ func (this *OpNode) GoString() string {
 	if this.Components != nil {
 		s = append(s, "Components: "+fmt.Sprintf("%#v", this.Components)+",\n")
 	}
+	s = append(s, "GraphExpr: "+fmt.Sprintf("%#v", this.GraphExpr)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -1009,6 +1022,13 @@ func (m *OpNode) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if len(m.GraphExpr) > 0 {
+		i -= len(m.GraphExpr)
+		copy(dAtA[i:], m.GraphExpr)
+		i = encodeVarintGraph(dAtA, i, uint64(len(m.GraphExpr)))
+		i--
+		dAtA[i] = 0x2a
+	}
 	if len(m.Components) > 0 {
 		for iNdEx := len(m.Components) - 1; iNdEx >= 0; iNdEx-- {
 			{
@@ -1298,6 +1318,10 @@ func (m *OpNode) Size() (n int) {
 			n += 1 + l + sovGraph(uint64(l))
 		}
 	}
+	l = len(m.GraphExpr)
+	if l > 0 {
+		n += 1 + l + sovGraph(uint64(l))
+	}
 	return n
 }
 
@@ -1429,6 +1453,7 @@ func (this *OpNode) String() string {
 		`VtxID:` + fmt.Sprintf("%v", this.VtxID) + `,`,
 		`Repeat:` + fmt.Sprintf("%v", this.Repeat) + `,`,
 		`Components:` + repeatedStringForComponents + `,`,
+		`GraphExpr:` + fmt.Sprintf("%v", this.GraphExpr) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -1622,6 +1647,38 @@ func (m *OpNode) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field GraphExpr", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowGraph
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthGraph
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthGraph
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.GraphExpr = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipGraph(dAtA[iNdEx:])
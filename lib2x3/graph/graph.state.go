@@ -0,0 +1,475 @@
+package graph
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/fine-structures/fine.SDK/go2x3"
+)
+
+// VtxState is a go2x3.State backed directly by a VtxGraphVM.
+//
+// Unlike a 2x3 graph, an edge of a VtxState may have any (signed) weight and a vertex may have any number of edges,
+// so it can hold graphs derived from 2x3 graphs (e.g. see VtxGraphVM.EdgeGroupDual).
+type VtxState struct {
+	edges      []VtxEdge  // edges as added, where SrcVtxID <= DstVtxID (a loop if equal) and Count is the edge weight
+	vm         VtxGraphVM // built from edges (never canonized)
+	vmErr      error      // set if vm could not be built from edges
+	dirty      bool       // set if vm needs to be rebuilt from edges
+	canon      VtxGraphVM // vm canonized, built as needed
+	canonErr   error      // set if canon could not be built
+	canonDirty bool       // set if canon needs to be rebuilt since vm was
+}
+
+func NewVtxState(Xsrc *VtxState) *VtxState {
+	X := vtxStatePool.Get().(*VtxState)
+	X.Init(Xsrc)
+	return X
+}
+
+var vtxStatePool = sync.Pool{
+	New: func() interface{} {
+		return new(VtxState)
+	},
+}
+
+// Init resets this VtxState to a copy of Xsrc (or to an empty graph if Xsrc is nil).
+func (X *VtxState) Init(Xsrc *VtxState) {
+	if X == Xsrc {
+		return
+	}
+	X.edges = X.edges[:0]
+	if Xsrc != nil {
+		X.edges = append(X.edges, Xsrc.edges...)
+	}
+	X.dirty = true
+}
+
+// AddEdge adds an edge of the given weight connecting vi and vj (one-based), where vi == vj denotes a loop.
+// Returns go2x3.ErrBadEdge if the weight exceeds the range of an int32 (as a VtxGraphVM edge count is limited to).
+func (X *VtxState) AddEdge(weight int64, vi, vj uint32) error {
+	if vi < 1 || vj < 1 || vi > MaxVertexID || vj > MaxVertexID {
+		return go2x3.ErrInvalidVtxID
+	}
+	if weight > math.MaxInt32 || weight < -math.MaxInt32 {
+		return go2x3.ErrBadEdge
+	}
+	if weight == 0 {
+		return nil
+	}
+	if vi > vj {
+		vi, vj = vj, vi
+	}
+	X.edges = append(X.edges, VtxEdge{
+		SrcVtxID: vi,
+		DstVtxID: vj,
+		Count:    weight,
+	})
+	X.dirty = true
+	return nil
+}
+
+// Edges returns the edges of this graph as added (see AddEdge), where Count is the edge weight.
+func (X *VtxState) Edges() []VtxEdge {
+	return X.edges
+}
+
+// VM returns the (uncanonized) VtxGraphVM for this graph, built from its edges as needed.
+// If this graph has no edges (or its VM could not be built), nil is returned.
+//
+// The returned VM must not be canonized since canonizing consolidates its vertices and edges (see Canonize).
+func (X *VtxState) VM() *VtxGraphVM {
+	vm, _ := X.buildVM()
+	return vm
+}
+
+// buildVM is VM but returns the error that prevented the VM from being built, or go2x3.ErrNilGraph if this graph has no edges.
+func (X *VtxState) buildVM() (*VtxGraphVM, error) {
+	if X.dirty {
+		X.vmErr = X.addEdgesTo(&X.vm)
+		X.dirty = false
+		X.canonDirty = true
+	}
+	if X.vmErr != nil {
+		return nil, X.vmErr
+	}
+	if X.vm.Status < GraphStatus_Validated || X.vm.VtxCount() == 0 {
+		return nil, go2x3.ErrNilGraph
+	}
+	return &X.vm, nil
+}
+
+// addEdgesTo resets vm to this graph's edges.
+func (X *VtxState) addEdgesTo(vm *VtxGraphVM) error {
+	vm.ResetGraph()
+	for _, e := range X.edges {
+		numNeg, numPos := int32(0), int32(e.Count)
+		if e.Count < 0 {
+			numNeg, numPos = int32(-e.Count), 0
+		}
+		if err := vm.AddEdge(numNeg, numPos, e.SrcVtxID, e.DstVtxID); err != nil {
+			return err
+		}
+	}
+	return vm.Validate()
+}
+
+func (X *VtxState) VertexCount() int {
+	if vm := X.VM(); vm != nil {
+		return vm.VtxCount()
+	}
+	return 0
+}
+
+func (X *VtxState) Traces(numTraces int) go2x3.Traces {
+	if vm := X.VM(); vm != nil {
+		return vm.Traces(numTraces)
+	}
+	return nil
+}
+
+// CheckedTraces is Traces but returns go2x3.ErrTracesOverflow if any of the requested Traces overflowed.
+func (X *VtxState) CheckedTraces(numTraces int) (go2x3.Traces, error) {
+	vm, err := X.buildVM()
+	if err != nil {
+		return nil, err
+	}
+	return vm.CheckedTraces(numTraces)
+}
+
+// BigTraces returns the requested number of Traces (0 denotes the vertex count) computed exactly.
+func (X *VtxState) BigTraces(numTraces int) go2x3.BigTraces {
+	if vm := X.VM(); vm != nil {
+		return vm.BigTraces(numTraces)
+	}
+	return nil
+}
+
+// NonBacktrackingTraces returns the requested number of non-backtracking Traces (0 denotes the vertex count).
+func (X *VtxState) NonBacktrackingTraces(numTraces int) (go2x3.Traces, error) {
+	vm, err := X.buildVM()
+	if err != nil {
+		return nil, err
+	}
+	return vm.NonBacktrackingTraces(numTraces)
+}
+
+// EdgeGroupDual returns this graph's edge group dual (see VtxGraphVM.EdgeGroupDual).
+func (X *VtxState) EdgeGroupDual() (go2x3.State, error) {
+	vm, err := X.canonized()
+	if err != nil {
+		return nil, err
+	}
+	dual, err := vm.EdgeGroupDual()
+	if err != nil {
+		return nil, err
+	}
+	return dual, nil
+}
+
+// WalkMatrices returns this graph's walk-count matrices, either per vertex or per consolidated vtx group
+// (see VtxGraphVM.WalkMatrices and GroupWalkMatrices).
+func (X *VtxState) WalkMatrices(numTraces int, byGroup bool) ([]go2x3.WalkMatrix, error) {
+	vm, err := X.buildVM()
+	if err != nil {
+		return nil, err
+	}
+	if byGroup {
+		return vm.GroupWalkMatrices(numTraces)
+	}
+	return vm.WalkMatrices(numTraces)
+}
+
+// PermuteEdgeSigns emits a VtxState for every sign permutation of this graph's (non-loop) edges, where each edge
+// (of any weight) is either positive or negative.
+func (X *VtxState) PermuteEdgeSigns(dst *go2x3.GraphStream) {
+	X.permuteSigns(dst, func(e *VtxEdge) bool {
+		return e.SrcVtxID != e.DstVtxID
+	})
+}
+
+// PermuteVtxSigns emits a VtxState for every sign permutation of this graph's loops (vertex poles).
+func (X *VtxState) PermuteVtxSigns(dst *go2x3.GraphStream) {
+	X.permuteSigns(dst, func(e *VtxEdge) bool {
+		return e.SrcVtxID == e.DstVtxID
+	})
+}
+
+// permuteSigns emits a copy of this graph for every sign permutation of the edges selected by permutes().
+func (X *VtxState) permuteSigns(dst *go2x3.GraphStream, permutes func(e *VtxEdge) bool) {
+	if len(X.edges) == 0 {
+		return
+	}
+
+	Xi := NewVtxState(X)
+	defer Xi.Reclaim()
+
+	// Start with each permuted edge positive
+	var perm []int
+	for i := range Xi.edges {
+		if e := &Xi.edges[i]; permutes(e) {
+			e.Count = absCount(e.Count)
+			perm = append(perm, i)
+		}
+	}
+
+	for {
+		if !dst.PushGraph(Xi) {
+			return
+		}
+
+		// "Increment" to the next permutation, where a negative edge is a set bit
+		carry := true
+		for _, i := range perm {
+			e := &Xi.edges[i]
+			e.Count = -e.Count
+			if e.Count < 0 {
+				carry = false
+				break
+			}
+		}
+		Xi.dirty = true
+
+		if carry {
+			break
+		}
+	}
+}
+
+// Canonize canonizes this graph's VtxGraphVM (see VtxGraphVM.Canonize), leaving this graph's edges unchanged.
+// normalize has no effect since a VtxGraphVM is always normalized as it is canonized.
+func (X *VtxState) Canonize(normalize bool) error {
+	_, err := X.canonized()
+	return err
+}
+
+// canonized returns this graph's canonized VtxGraphVM, which is kept apart from VM so that neither is rebuilt for the other.
+func (X *VtxState) canonized() (*VtxGraphVM, error) {
+	if _, err := X.buildVM(); err != nil {
+		return nil, err
+	}
+	if X.canonDirty {
+		X.canonErr = X.addEdgesTo(&X.canon)
+		if X.canonErr == nil {
+			X.canonErr = X.canon.Canonize()
+		}
+		X.canonDirty = false
+	}
+	if X.canonErr != nil {
+		return nil, X.canonErr
+	}
+	return &X.canon, nil
+}
+
+// AppendGraphExpr appends this graph's edges as a comma separated list of "vi-vj*weight" terms (e.g. "1-1*2,1-2*-1").
+func (X *VtxState) AppendGraphExpr(out []byte) []byte {
+	edges := append([]VtxEdge{}, X.edges...)
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].SrcVtxID != edges[j].SrcVtxID {
+			return edges[i].SrcVtxID < edges[j].SrcVtxID
+		}
+		return edges[i].DstVtxID < edges[j].DstVtxID
+	})
+	for i, e := range edges {
+		if i > 0 {
+			out = append(out, ',')
+		}
+		out = fmt.Appendf(out, "%d-%d*%d", e.SrcVtxID, e.DstVtxID, e.Count)
+	}
+	return out
+}
+
+func (X *VtxState) WriteCSV(out io.Writer, opts go2x3.PrintOpts) error {
+	var scrap [512]byte
+	info := X.GraphInfo()
+	line := fmt.Appendf(scrap[:0], "p=%d,v=%d,", info.NumParticles, info.NumVertex)
+	line = strconv.AppendQuote(line, string(X.AppendGraphExpr(nil)))
+	line = append(line, ',')
+
+	if opts.NumTraces != 0 {
+		for _, Ti := range X.Traces(opts.NumTraces) {
+			line = strconv.AppendInt(line, Ti, 10)
+			line = append(line, ',')
+		}
+	}
+	if len(opts.Normalize) > 0 {
+		var err error
+		if line, err = go2x3.AppendNormalizedCSV(line, X.Traces(opts.NumTraces), X.VertexCount(), opts.Normalize); err != nil {
+			return err
+		}
+	}
+	out.Write(line)
+
+	if opts.CycleSpec || opts.Primitive || opts.EdgeGroup {
+		out.Write([]byte{'\n'})
+		vm, err := X.canonized()
+		if err != nil {
+			return err
+		}
+		if opts.CycleSpec {
+			vm.PrintCycleSpectrum(12, out)
+		}
+		if opts.Primitive {
+			vm.PrintPrimitiveCycleSpectrum(12, out)
+		}
+		if opts.EdgeGroup {
+			if err := vm.PrintEdgeGroupSpectrum(12, out); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// MarshalOut appends this graph's canonic encoding (see VtxGraphVM.AppendGraphEncoding) if go2x3.AsState is given,
+// making it usable as a catalog key.  If go2x3.AsValue is given, a GraphDef holding this graph's edges is appended
+// (see InitFromDef).
+func (X *VtxState) MarshalOut(out []byte, opts go2x3.MarshalOpts) ([]byte, error) {
+	switch {
+	case opts&go2x3.AsValue != 0:
+		def := GraphDef{
+			GraphEncoding: X.appendEdgeEncoding(nil),
+		}
+		buf, err := def.Marshal()
+		if err != nil {
+			return nil, err
+		}
+		return append(out, buf...), nil
+
+	case opts&go2x3.AsState != 0:
+		vm, err := X.canonized()
+		if err != nil {
+			return nil, err
+		}
+		return vm.AppendGraphEncoding(out, 0)
+	}
+	return nil, go2x3.ErrNotSupported
+}
+
+// A VtxState's GraphDef.GraphEncoding lists its edges as added, where all values are uvarints (except Count):
+//
+//	0x00                        marker, since a legacy graph encoding starts with its (non-zero) particle count
+//	NumEdges
+//	for each edge:
+//	    SrcVtxID
+//	    DstVtxID
+//	    Count                   edge weight (varint)
+const vtxStateEncodingMarker = 0x00
+
+// IsVtxStateEncoding returns true if the given GraphDef.GraphEncoding was made by VtxState.MarshalOut.
+func IsVtxStateEncoding(Xenc []byte) bool {
+	return len(Xenc) > 0 && Xenc[0] == vtxStateEncodingMarker
+}
+
+func (X *VtxState) appendEdgeEncoding(out []byte) []byte {
+	out = append(out, vtxStateEncodingMarker)
+	out = binary.AppendUvarint(out, uint64(len(X.edges)))
+	for _, e := range X.edges {
+		out = binary.AppendUvarint(out, uint64(e.SrcVtxID))
+		out = binary.AppendUvarint(out, uint64(e.DstVtxID))
+		out = binary.AppendVarint(out, e.Count)
+	}
+	return out
+}
+
+// NewVtxStateFromDef returns a new VtxState from a GraphDef made by MarshalOut (see InitFromDef).
+func NewVtxStateFromDef(graphDef []byte) (*VtxState, error) {
+	X := NewVtxState(nil)
+	if err := X.InitFromDef(graphDef); err != nil {
+		X.Reclaim()
+		return nil, err
+	}
+	return X, nil
+}
+
+// InitFromDef assigns this graph from a GraphDef made by MarshalOut with go2x3.AsValue.
+func (X *VtxState) InitFromDef(graphDef []byte) error {
+	def := GraphDef{}
+	if err := def.Unmarshal(graphDef); err != nil {
+		return err
+	}
+
+	return X.InitFromEncoding(def.GraphEncoding)
+}
+
+// InitFromEncoding assigns this graph from a GraphDef.GraphEncoding made by MarshalOut (see IsVtxStateEncoding).
+func (X *VtxState) InitFromEncoding(Xenc []byte) error {
+	if !IsVtxStateEncoding(Xenc) {
+		return go2x3.ErrBadEncoding
+	}
+	Xenc = Xenc[1:]
+
+	next := func() uint64 {
+		val, n := binary.Uvarint(Xenc)
+		if n <= 0 {
+			Xenc = nil
+			return 0
+		}
+		Xenc = Xenc[n:]
+		return val
+	}
+
+	X.Init(nil)
+	for numEdges := next(); numEdges > 0; numEdges-- {
+		vi, vj := next(), next()
+		weight, n := binary.Varint(Xenc)
+		if n <= 0 {
+			return go2x3.ErrBadEncoding
+		}
+		Xenc = Xenc[n:]
+		if err := X.AddEdge(weight, uint32(vi), uint32(vj)); err != nil {
+			return go2x3.ErrBadEncoding
+		}
+	}
+	if len(Xenc) != 0 {
+		return go2x3.ErrBadEncoding
+	}
+	return nil
+}
+
+func (X *VtxState) MakeCopy() go2x3.State {
+	return NewVtxState(X)
+}
+
+func (X *VtxState) GraphInfo() go2x3.GraphInfo {
+	info := go2x3.GraphInfo{}
+	if vm := X.VM(); vm != nil {
+		info.NumVertex = byte(vm.VtxCount())
+		for _, v := range vm.Vtx() {
+			info.NumParticles = max(info.NumParticles, byte(v.GraphID))
+		}
+	}
+
+	for _, e := range X.edges {
+		n := byte(min(absCount(e.Count), 0xFF))
+		switch {
+		case e.SrcVtxID == e.DstVtxID && e.Count < 0:
+			info.NegLoops += n
+		case e.SrcVtxID == e.DstVtxID:
+			info.PosLoops += n
+		case e.Count < 0:
+			info.NegEdges += n
+		default:
+			info.PosEdges += n
+		}
+	}
+	return info
+}
+
+func absCount(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func (X *VtxState) Reclaim() {
+	if X != nil {
+		vtxStatePool.Put(X)
+	}
+}
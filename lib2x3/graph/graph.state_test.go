@@ -0,0 +1,73 @@
+package graph
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fine-structures/fine.SDK/go2x3"
+)
+
+func TestVtxState(t *testing.T) {
+	X := newTestState(t, [][3]int64{{1, 2, 1}, {2, 3, 2}, {1, 3, -1}, {3, 3, 1}, {1, 1, -2}})
+	defer X.Reclaim()
+	TX := X.Traces(8)
+
+	if err := X.AddEdge(1<<31, 1, 2); err != go2x3.ErrBadEdge {
+		t.Fatalf("expected ErrBadEdge for a weight beyond int32, got %v", err)
+	}
+
+	// marshalled as a value, the graph's edges are restored as added
+	val, err := X.MarshalOut(nil, go2x3.AsValue)
+	if err != nil {
+		t.Fatal(err)
+	}
+	X2, err := NewVtxStateFromDef(val)
+	if err != nil || string(X2.AppendGraphExpr(nil)) != string(X.AppendGraphExpr(nil)) || !X2.Traces(8).IsEqual(TX) {
+		t.Fatalf("AsValue round trip: %v", err)
+	}
+	if _, err := NewVtxStateFromDef(val[:len(val)-1]); err == nil {
+		t.Fatal("expected truncated GraphDef to fail")
+	}
+	if _, err := X.MarshalOut(nil, go2x3.AsState); err != nil || X.Canonize(false) != nil || !X.Traces(8).IsEqual(TX) {
+		t.Fatalf("AsState: %v", err)
+	}
+
+	// canonizing leaves the uncanonized VM intact, so neither is rebuilt for the other
+	vm := X.VM()
+	canon, err := X.canonized()
+	if err != nil || vm == canon || vm.Status >= GraphStatus_Canonized || canon.Status < GraphStatus_Canonized {
+		t.Fatalf("canonized: %v", err)
+	}
+	if X.VM() != vm || X.dirty || X.canonDirty {
+		t.Fatal("VM was rebuilt after Canonize")
+	}
+	if _, err := X.NonBacktrackingTraces(4); err != nil {
+		t.Fatalf("NonBacktrackingTraces after Canonize: %v", err)
+	}
+
+	// each non-loop edge and each loop can be negated independently
+	ctx := context.Background()
+	tests := []struct {
+		name    string
+		permute func(*go2x3.GraphStream) *go2x3.GraphStream
+		count   int
+	}{
+		{"edges", func(s *go2x3.GraphStream) *go2x3.GraphStream { return s.PermuteEdgeSigns(ctx) }, 1 << 3},
+		{"vtx", func(s *go2x3.GraphStream) *go2x3.GraphStream { return s.PermuteVtxSigns(ctx) }, 1 << 2},
+	}
+	for _, test := range tests {
+		stream := test.permute(go2x3.StreamGraph(ctx, X))
+		distinct := map[string]bool{}
+		for Xi := range stream.All() {
+			expr := string(Xi.(*VtxState).AppendGraphExpr(nil))
+			info := Xi.GraphInfo()
+			if info.NumVertex != 3 || info.NegEdges+info.PosEdges != 4 || info.NegLoops+info.PosLoops != 3 {
+				t.Fatalf("%s: bad permutation %s", test.name, expr)
+			}
+			distinct[expr] = true
+		}
+		if len(distinct) != test.count || stream.Err() != nil {
+			t.Fatalf("%s: %d permutations, expected %d (%v)", test.name, len(distinct), test.count, stream.Err())
+		}
+	}
+}
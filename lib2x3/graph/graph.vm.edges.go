@@ -0,0 +1,196 @@
+package graph
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/fine-structures/fine.SDK/go2x3"
+)
+
+// Each edge "half" carries its own cycles vector (see ComputeEdge.Cycles), where the cycles of a vtx are the sum of the
+// cycles of its edges.  Since a closed walk traversed in reverse is also a closed walk, the two halves of a (non-loop) edge
+// have the same cycles vector.
+//
+// Edges having the same cycles vector are stacked into an EdgeGroup, so a vertex can be described by the EdgeGroups it connects.
+// Edges of differing sign but equal cycles group together (e.g. the mixed sign variants of a Higgs), normalizing them naturally.
+
+// EdgeGroup is a set of edge halves (in the same graph) all having the same cycles vector.
+type EdgeGroup struct {
+
+	// Canonic ordinal of this group within its graph, based on cycle vector ordering (first GroupID is 1).
+	GroupID uint32
+
+	// GraphID is the one-based index of the graph (particle) this group belongs to.
+	GraphID uint32
+
+	// Count is the number of edge halves in this group.
+	Count int64
+
+	// The cycles vector shared by each edge of this group (see ComputeEdge.Cycles).
+	// Contribution to Traces == Count * Cycles[]
+	Cycles []int64
+
+	// VtxIDs lists the (initially assigned) vtx that each edge half of this group flows into, in ascending order.
+	VtxIDs []uint32
+}
+
+func (eg *EdgeGroup) AppendDesc(io []byte) []byte {
+	if eg.GroupID == 1 {
+		io = fmt.Appendf(io, " %2d", eg.GraphID)
+	} else {
+		io = fmt.Append(io, "   ")
+	}
+	io = fmt.Appendf(io, ".e%-3d         %02d", eg.GroupID, eg.Count)
+	return io
+}
+
+// EdgeGroups returns this graph's edges grouped by their cycles vector, canonically ordered by GraphID and then by cycles vector.
+// The graph is canonized as needed (see Canonize).
+func (X *VtxGraphVM) EdgeGroups() ([]*EdgeGroup, error) {
+	if X.Status < GraphStatus_Validated {
+		return nil, go2x3.ErrNotSupported
+	}
+	if err := X.Canonize(); err != nil {
+		return nil, err
+	}
+	return X.edgeGroups, nil
+}
+
+// groupEdges sorts and stacks edges having the same cycles vector into X.edgeGroups.
+// pre: edge cycles have been computed and the vtx have not yet been consolidated.
+func (X *VtxGraphVM) groupEdges() {
+	edges := make([]*ComputeEdge, X.edgeCount)
+	copy(edges, X.edgePool[:X.edgeCount])
+
+	graphOf := func(e *ComputeEdge) uint32 {
+		return X.vtx[e.DstVtxID-1].GraphID
+	}
+
+	sort.SliceStable(edges, func(i, j int) bool {
+		ei := edges[i]
+		ej := edges[j]
+		if d := int(graphOf(ei)) - int(graphOf(ej)); d != 0 {
+			return d < 0
+		}
+		return compareEdgeCycles(ei, ej) < 0
+	})
+
+	X.edgeGroups = X.edgeGroups[:0]
+	var cur *EdgeGroup
+	var prev *ComputeEdge
+	for _, e := range edges {
+		graphID := graphOf(e)
+		if cur == nil || cur.GraphID != graphID || compareEdgeCycles(e, prev) != 0 {
+			groupID := uint32(1)
+			if cur != nil && cur.GraphID == graphID {
+				groupID = cur.GroupID + 1
+			}
+			cur = &EdgeGroup{
+				GroupID: groupID,
+				GraphID: graphID,
+				Cycles:  append([]int64{}, e.Cycles...),
+			}
+			X.edgeGroups = append(X.edgeGroups, cur)
+		}
+		cur.Count++
+		cur.VtxIDs = append(cur.VtxIDs, e.DstVtxID)
+		e.GroupID = cur.GroupID
+		prev = e
+	}
+
+	for _, eg := range X.edgeGroups {
+		sort.Slice(eg.VtxIDs, func(i, j int) bool {
+			return eg.VtxIDs[i] < eg.VtxIDs[j]
+		})
+	}
+}
+
+func compareEdgeCycles(a, b *ComputeEdge) int64 {
+	for i, ai := range a.Cycles {
+		d := ai - b.Cycles[i]
+		if d != 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// PrintEdgeGroupSpectrum is PrintCycleSpectrum for this graph's EdgeGroups, printing each group's edge count and cycles vector.
+func (X *VtxGraphVM) PrintEdgeGroupSpectrum(numTraces int, out io.Writer) error {
+	groups, err := X.EdgeGroups()
+	if err != nil {
+		return err
+	}
+	TX := X.Traces(numTraces)
+	Nc := len(TX)
+	if len(groups) > 0 {
+		Nc = min(Nc, len(groups[0].Cycles))
+		TX = TX[:Nc]
+	}
+
+	writeSpectrumHeader(TX, 'C', out)
+
+	var buf [128]byte
+	prOpts := PrintIntOpts{
+		MinWidth: len(gLineSep),
+	}
+	for _, eg := range groups {
+		line := eg.AppendDesc(buf[:0])
+		line = append(line, "  "...)
+		for _, Ci := range eg.Cycles[:Nc] {
+			line = AppendInt(line, Ci, prOpts)
+		}
+		line = append(line, '\n')
+		out.Write(line)
+	}
+	return nil
+}
+
+// EdgeGroupDual returns the "dual" of this graph, where each EdgeGroup becomes a vertex and shared vertices become edges:
+//
+//	W[a][b] = number of vertices having an edge in group a and an edge in group b
+//
+// So two edge groups are joined by an edge weighted by the number of vertices they share, and each edge group has a loop
+// weighted by the number of vertices it touches (i.e. W = Mᵀ M, where M[v][g] is 1 if vertex v has an edge in group g).
+// The dual's vertices are numbered in EdgeGroup order, so the dual of a canonized graph is itself canonic.
+func (X *VtxGraphVM) EdgeGroupDual() (*VtxState, error) {
+	groups, err := X.EdgeGroups()
+	if err != nil {
+		return nil, err
+	}
+	Ng := len(groups)
+	if Ng > MaxVertexID {
+		return nil, go2x3.ErrInvalidVtxID
+	}
+
+	// touches[v] lists the (zero-based) edge groups each initially assigned vertex has an edge in
+	touches := map[uint32][]int{}
+	for gi, eg := range groups {
+		for i, vtxID := range eg.VtxIDs {
+			if i == 0 || eg.VtxIDs[i-1] != vtxID {
+				touches[vtxID] = append(touches[vtxID], gi)
+			}
+		}
+	}
+
+	W := make([]int64, Ng*Ng)
+	for _, gs := range touches {
+		for _, a := range gs {
+			for _, b := range gs {
+				W[a*Ng+b]++
+			}
+		}
+	}
+
+	dual := NewVtxState(nil)
+	for a := 0; a < Ng; a++ {
+		for b := a; b < Ng; b++ {
+			if err := dual.AddEdge(W[a*Ng+b], uint32(a+1), uint32(b+1)); err != nil {
+				dual.Reclaim()
+				return nil, err
+			}
+		}
+	}
+	return dual, nil
+}
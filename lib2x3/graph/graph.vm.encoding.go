@@ -0,0 +1,220 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sort"
+
+	"github.com/fine-structures/fine.SDK/go2x3"
+)
+
+// A canonized VtxGraphVM is encoded as its consolidated vtx groups, where each group lists the summed weight of the
+// edges flowing into it from each group (a loop being from itself).  All values are uvarints:
+//
+//	NumGraphs
+//	for each graph (particle), in canonic order:
+//	    NumGroups
+//	    for each vtx group, in GroupID order:
+//	        Count                       number of vertices consolidated into this group
+//	        NumTerms                    number of groups having edges into this group
+//	        for each term, in GroupID order:
+//	            SrcGroupID              (one-based) GroupID of the group the edges flow from
+//	            |Weight|                magnitude of the summed edge weight
+//	sign bits                           one bit per term (in the order above, packed LSB first), set if its Weight is negative
+//
+// Signs are encoded last so that graphs differing only by sign share the same prefix (and so sort adjacently).
+// Since a graph's GraphIDs follow the order its edges were added, graphs are ordered by their encoding rather than GraphID.
+//
+// Open questions remain about when graphs are redundant and can be dropped, for example:
+//  1. same canonization but different sign distribution (e.g. elastance of two Higgs 24+08)
+//     -- current view: counted as a dupe (see GraphEncoding_OmitSigns)
+//  2. graphs with canonization synonyms (e.g. K8 with or without C1)
+//     -- "normalization" is to choose the canonic form
+//  3. graph canonized with dropped sign "deltas" (e.g. Higgs normalized to 24)
+//     -- requires a "normalization" step to choose the canonic form
+//     -- must prove that the canonic form is unique (1:1 mapping of existing Traces and this new form)
+
+type GraphEncodingOpts int
+
+const (
+	// Omits the sign bits, so graphs differing only in edge signs have the same encoding.
+	GraphEncoding_OmitSigns GraphEncodingOpts = 1 << iota
+)
+
+// encodedGraph is the encoding of a single graph (particle), with its sign bits held separately.
+type encodedGraph struct {
+	numGroups int
+	enc       []byte // each group's Count and terms
+	signs     []bool // sign of each term, set if negative
+}
+
+// AppendGraphEncoding appends the canonic encoding of this graph to io, canonizing it as needed.
+//
+// Two graphs have the same encoding if their canonized vtx groups and group edges are the same, independent of the
+// order in which their vertices, edges, and particles were added.  See DecodeGraphEncoding.
+//
+// Returns go2x3.ErrTracesOverflow if the graph could not be canonized.
+func (X *VtxGraphVM) AppendGraphEncoding(io []byte, opts GraphEncodingOpts) ([]byte, error) {
+	if X.Status < GraphStatus_Validated {
+		return nil, go2x3.ErrNilGraph
+	}
+	if err := X.Canonize(); err != nil {
+		return nil, err
+	}
+
+	var graphs []*encodedGraph
+	var cur *encodedGraph
+	weights := map[uint32]int64{}
+	srcIDs := make([]uint32, 0, 8)
+
+	// X.vtx is ordered by GraphID and then GroupID
+	for _, v := range X.Vtx() {
+		if v.GroupID == 1 {
+			cur = &encodedGraph{}
+			graphs = append(graphs, cur)
+		}
+		cur.numGroups++
+
+		// Sum the edge weights flowing into this group by the group they flow from
+		clear(weights)
+		for _, e := range v.Edges {
+			weights[e.SrcVtxID] += e.Count
+		}
+		srcIDs = srcIDs[:0]
+		for srcID, w := range weights {
+			if w != 0 {
+				srcIDs = append(srcIDs, srcID)
+			}
+		}
+		sort.Slice(srcIDs, func(i, j int) bool {
+			return srcIDs[i] < srcIDs[j]
+		})
+
+		cur.enc = binary.AppendUvarint(cur.enc, uint64(v.Count))
+		cur.enc = binary.AppendUvarint(cur.enc, uint64(len(srcIDs)))
+		for _, srcID := range srcIDs {
+			w := weights[srcID]
+			cur.enc = binary.AppendUvarint(cur.enc, uint64(srcID))
+			cur.enc = binary.AppendUvarint(cur.enc, uint64(absCount(w)))
+			cur.signs = append(cur.signs, w < 0)
+		}
+	}
+
+	sort.SliceStable(graphs, func(i, j int) bool {
+		gi, gj := graphs[i], graphs[j]
+		if gi.numGroups != gj.numGroups {
+			return gi.numGroups < gj.numGroups
+		}
+		if d := bytes.Compare(gi.enc, gj.enc); d != 0 {
+			return d < 0
+		}
+		for k, si := range gi.signs {
+			if sj := gj.signs[k]; si != sj {
+				return sj
+			}
+		}
+		return false
+	})
+
+	io = binary.AppendUvarint(io, uint64(len(graphs)))
+	for _, g := range graphs {
+		io = binary.AppendUvarint(io, uint64(g.numGroups))
+		io = append(io, g.enc...)
+	}
+
+	if opts&GraphEncoding_OmitSigns == 0 {
+		bits, n := byte(0), 0
+		for _, g := range graphs {
+			for _, neg := range g.signs {
+				if neg {
+					bits |= 1 << n
+				}
+				if n++; n == 8 {
+					io = append(io, bits)
+					bits, n = 0, 0
+				}
+			}
+		}
+		if n > 0 {
+			io = append(io, bits)
+		}
+	}
+	return io, nil
+}
+
+// DecodeGraphEncoding decodes an encoding made by VtxGraphVM.AppendGraphEncoding, returning its vtx groups ordered by
+// GraphID and then GroupID.  Each group's Edges hold its terms, where an edge's DstVtxID and SrcVtxID are GroupIDs
+// (in the same graph) and Count is the summed edge weight.  Cycles are not encoded and so are left empty.
+//
+// If the sign bits were omitted (see GraphEncoding_OmitSigns), all weights are returned as positive.
+func DecodeGraphEncoding(enc []byte) ([]*VtxGroup, error) {
+	next := func() (uint64, error) {
+		val, n := binary.Uvarint(enc)
+		if n <= 0 {
+			return 0, go2x3.ErrBadEncoding
+		}
+		enc = enc[n:]
+		return val, nil
+	}
+
+	numGraphs, err := next()
+	if err != nil || numGraphs > MaxVertexID {
+		return nil, go2x3.ErrBadEncoding
+	}
+
+	var groups []*VtxGroup
+	var edges []*VtxEdge
+	for graphID := uint32(1); graphID <= uint32(numGraphs); graphID++ {
+		numGroups, err := next()
+		if err != nil || numGroups < 1 || numGroups > MaxVertexID {
+			return nil, go2x3.ErrBadEncoding
+		}
+		for groupID := uint32(1); groupID <= uint32(numGroups); groupID++ {
+			count, err := next()
+			if err != nil {
+				return nil, err
+			}
+			numTerms, err := next()
+			if err != nil || numTerms > numGroups {
+				return nil, go2x3.ErrBadEncoding
+			}
+			g := &VtxGroup{
+				GroupID: groupID,
+				GraphID: graphID,
+				Count:   int64(count),
+			}
+			for ; numTerms > 0; numTerms-- {
+				srcID, err := next()
+				if err != nil || srcID < 1 || srcID > numGroups {
+					return nil, go2x3.ErrBadEncoding
+				}
+				w, err := next()
+				if err != nil {
+					return nil, err
+				}
+				e := &VtxEdge{
+					DstVtxID: groupID,
+					SrcVtxID: uint32(srcID),
+					Count:    int64(w),
+				}
+				g.Edges = append(g.Edges, e)
+				edges = append(edges, e)
+			}
+			groups = append(groups, g)
+		}
+	}
+
+	// What remains are the sign bits (if present)
+	switch len(enc) {
+	case 0:
+	case (len(edges) + 7) / 8:
+		for i, e := range edges {
+			if enc[i>>3]&(1<<(i&7)) != 0 {
+				e.Count = -e.Count
+			}
+		}
+	default:
+		return nil, go2x3.ErrBadEncoding
+	}
+	return groups, nil
+}
@@ -0,0 +1,91 @@
+package graph
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fine-structures/fine.SDK/go2x3"
+)
+
+// newTestState returns a new VtxState having the given edges, each as {vi, vj, weight}.
+func newTestState(t *testing.T, edges [][3]int64) *VtxState {
+	t.Helper()
+	X := NewVtxState(nil)
+	for _, e := range edges {
+		if err := X.AddEdge(e[2], uint32(e[0]), uint32(e[1])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return X
+}
+
+func TestVtxGraphEncoding(t *testing.T) {
+	encode := func(edges [][3]int64, opts GraphEncodingOpts) []byte {
+		X := newTestState(t, edges)
+		defer X.Reclaim()
+		enc, err := X.VM().AppendGraphEncoding(nil, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return enc
+	}
+
+	// a path with a loop at one end plus a disjoint edge
+	path := [][3]int64{{1, 2, 1}, {2, 3, 1}, {3, 3, 2}, {4, 5, 1}}
+	negated := [][3]int64{{1, 2, 1}, {2, 3, 1}, {3, 3, 2}, {4, 5, -1}}
+
+	tests := []struct {
+		name  string
+		edges [][3]int64
+		opts  GraphEncodingOpts
+		same  bool // if set, encodes the same as path
+	}{
+		{"relabeled", [][3]int64{{1, 2, 1}, {5, 5, 2}, {4, 5, 1}, {3, 4, 1}}, 0, true},
+		{"negated", negated, 0, false},
+		{"negated without signs", negated, GraphEncoding_OmitSigns, true},
+		{"extra loop", append([][3]int64{{1, 1, 1}}, path...), 0, false},
+	}
+	for _, test := range tests {
+		if same := bytes.Equal(encode(test.edges, test.opts), encode(path, test.opts)); same != test.same {
+			t.Fatalf("%s: expected same encoding %v", test.name, test.same)
+		}
+	}
+
+	// sign bits are last, so they can be omitted
+	encPath, encNeg := encode(path, 0), encode(negated, 0)
+	if !bytes.HasPrefix(encNeg, encode(negated, GraphEncoding_OmitSigns)) {
+		t.Fatalf("expected sign bits last: %x", encNeg)
+	}
+	X := newTestState(t, path)
+	if state, err := X.MarshalOut(nil, go2x3.AsState); err != nil || !bytes.Equal(state, encPath) {
+		t.Fatalf("MarshalOut: %x (%v)", state, err)
+	}
+	X.Reclaim()
+
+	// decoding gives back the vtx groups and the summed weight of the edges between them (two halves per edge, one per loop)
+	for _, enc := range [][]byte{encPath, encNeg} {
+		groups, err := DecodeGraphEncoding(enc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		numVtx, sum, numNeg := int64(0), int64(0), 0
+		for _, g := range groups {
+			numVtx += g.Count
+			for _, e := range g.Edges {
+				if e.DstVtxID != g.GroupID {
+					t.Fatal("bad decoded edge")
+				}
+				sum += absCount(e.Count)
+				if e.Count < 0 {
+					numNeg++
+				}
+			}
+		}
+		if numVtx != 5 || sum != 2*3+2 || groups[len(groups)-1].GraphID != 2 || (numNeg > 0) != bytes.Equal(enc, encNeg) {
+			t.Fatalf("%x: decoded %d vtx, total weight %d, %d negative edges", enc, numVtx, sum, numNeg)
+		}
+	}
+	if _, err := DecodeGraphEncoding(append(encNeg, 0)); err != go2x3.ErrBadEncoding {
+		t.Fatalf("expected ErrBadEncoding, got %v", err)
+	}
+}
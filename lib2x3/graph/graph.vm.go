@@ -93,15 +93,31 @@ type ComputeVtx struct {
 	Ci1 []int64 // trace in place
 }
 
+// ComputeEdge is an edge "half" (flowing from SrcVtxID into DstVtxID) along with the cycles it contributes to its DstVtxID.
+type ComputeEdge struct {
+	VtxEdge
+
+	// EdgeGroup assignment (see EdgeGroup.GroupID); 0 denotes unassigned.
+	GroupID uint32
+
+	// Cycles[i] is the number of closed walks of length i+1 from DstVtxID whose last step traverses this edge.
+	// Summing Cycles over the edges of a vtx gives that vtx's Cycles.
+	Cycles []int64
+}
+
 type VtxGraphVM struct {
 	Status GraphStatus
 
-	edgeCount int        // allocated edges: edgePool[:edgeCount]
-	edgePool  []*VtxEdge // used and non-used edges
-	traces    []int64
-	calcBuf   []int64
-	vtx       []*ComputeVtx // Vtx by VtxID (zero-based indexing)
-	vtxMap    []uint32      // original VtxID to consolidated VtxID (zero-based indexing)
+	edgeCount  int              // allocated edges: edgePool[:edgeCount]
+	edgePool   []*ComputeEdge   // used and non-used edges
+	edgeGroups []*EdgeGroup     // edges grouped by cycles vector (see Canonize)
+	dstEdges   [][]*ComputeEdge // edges by the vtx they flow into, set by calcTracesTo (zero-based indexing)
+	traces     []int64
+	calcBuf    []int64
+	vtx        []*ComputeVtx // Vtx by VtxID (zero-based indexing)
+	vtxMap     []uint32      // original VtxID to consolidated VtxID (zero-based indexing)
+	vtxGroup   []int         // original VtxID to the index of its consolidated vtx in X.vtx, set by Canonize (zero-based indexing)
+	adj        []int64       // adjacency matrix (row-major) of the graph as added, set by Validate
 }
 
 const maxNv = 18
@@ -212,25 +228,26 @@ func (X *VtxGraphVM) ResetGraph() {
 
 	X.vtx = X.vtx[:0]
 	X.edgeCount = 0
+	X.edgeGroups = X.edgeGroups[:0]
 	X.traces = nil
 	X.Status = GraphStatus_Invalid
 }
 
-func (X *VtxGraphVM) newEdge() *VtxEdge {
+func (X *VtxGraphVM) newEdge() *ComputeEdge {
 	Ne := X.edgeCount
 
 	if cap(X.edgePool) <= Ne {
 		old := X.edgePool
-		X.edgePool = make([]*VtxEdge, 16+2*cap(X.edgePool))
+		X.edgePool = make([]*ComputeEdge, 16+2*cap(X.edgePool))
 		copy(X.edgePool, old)
 	}
 
 	e := X.edgePool[Ne]
 	if e == nil {
-		e = &VtxEdge{}
+		e = &ComputeEdge{}
 		X.edgePool[Ne] = e
 	} else {
-		*e = VtxEdge{}
+		*e = ComputeEdge{}
 	}
 	X.edgeCount = Ne + 1
 	return e
@@ -265,7 +282,7 @@ func (X *VtxGraphVM) AddEdge(
 		count := int64(numPos) - int64(numNeg)
 		ei.Count = count
 
-		X.addEdgeToVtx(vi, ei)
+		X.addEdgeToVtx(vi, &ei.VtxEdge)
 
 		// Add the other flow edge to the other vertex
 		if adding == 2 {
@@ -317,16 +334,39 @@ func (X *VtxGraphVM) Validate() error {
 			v.GraphID = uint32(remap[v.GraphID])
 		}
 	}
+	// Retain the adjacency of the graph as added since Canonize consolidates vtx and edges
+	{
+		Nv := len(vtx)
+		X.adj = append(X.adj[:0], make([]int64, Nv*Nv)...)
+		for j, vj := range vtx {
+			for _, e := range vj.Edges {
+				X.adj[j*Nv+int(e.SrcVtxID)-1] += e.Count
+			}
+		}
+	}
+
 	if X.Status < GraphStatus_Validated {
 		X.Status = GraphStatus_Validated
 	}
 	return nil
 }
 
-func (X *VtxGraphVM) Canonize() {
-	X.Traces(24)
+// Canonize groups this graph's edges (see EdgeGroups) and consolidates and normalizes its vertices by their cycle vectors
+// (computed up to C24).  Canonizing an already canonized graph has no effect.
+//
+// Returns go2x3.ErrTracesOverflow (leaving X unchanged) if the cycle counts overflow an int64.
+func (X *VtxGraphVM) Canonize() error {
+	if X.Status >= GraphStatus_Canonized {
+		return nil
+	}
+	if _, err := X.CheckedTraces(24); err != nil {
+		return err
+	}
+	X.groupEdges()
 	X.consolidateVtx()
 	X.normalize()
+	X.Status = GraphStatus_Canonized
+	return nil
 }
 
 func compareCycles(a, b *ComputeVtx) int64 {
@@ -406,10 +446,12 @@ func (X *VtxGraphVM) normalize() {
 	// Reassign VtxID to be final group ID
 	{
 		vtxToGrpID := make([]uint32, len(X.vtxMap))
+		X.vtxGroup = X.vtxGroup[:0]
 		for wasVtxID, nowVtxID := range X.vtxMap {
-			for _, vi := range vtx {
+			for i, vi := range vtx {
 				if vi.VtxID == nowVtxID {
 					vtxToGrpID[wasVtxID] = vi.GroupID
+					X.vtxGroup = append(X.vtxGroup, i)
 					break
 				}
 			}
@@ -657,6 +699,12 @@ func (X *VtxGraphVM) calcTracesTo(Nc int) {
 		X.vtxMap[i] = uint32(i + 1)
 	}
 
+	// Edge cycles are only needed to group edges (see Canonize), so only then are edges bucketed by the vtx they flow into
+	var dstEdges [][]*ComputeEdge
+	if X.Status < GraphStatus_Canonized {
+		dstEdges = X.edgesByDstVtx()
+	}
+
 	// Oh Lord, our Adonai and God, you alone are the Lord. You have made the heavens, the heaven of heavens, with all their host, the earth and all that is on it, the seas and all that is in them; and you preserve all of them; and the host of heaven worships you. You are the Lord, the God, who chose Abram and brought him out of Ur of the Chaldeans and gave him the name Abraham; you found his heart faithful before you, and made with him the covenant to give the land of the Canaanites, the Hittites, the Amorites, the Perizzites, the Jebusites, and the Girgashites—to give it to his offspring. You have kept your promise, for you are righteous. And you saw the affliction of our fathers in Egypt and heard their cry at the Red Sea; and you performed signs and wonders against Pharaoh and all his servants and all the people of his land, for you knew that they acted arrogantly against them. And you made a name for yourself, as it is this day, and you divided the sea before them, so that they went through the midst of the sea on dry land, and you cast their pursuers into the depths, as a stone into mighty waters. Moreover in a pillar of cloud you led them by day, and in a pillar of fire by night, to light for them the way in which they should go. You came down also upon Mount Sinai, and spoke with them from heaven, and gave them right ordinances and true laws, good statutes and commandments; and you made known to them your holy sabbath, and commanded them commandments and statutes, a law for ever. And you gave them bread from heaven for their hunger, and brought forth water for them out of the rock for their thirst, and you told them to go in to possess the land that you had sworn to give them. But they and our fathers acted presumptuously and stiffened their neck, and did not obey your commandments. They refused to obey, neither were mindful of the wonders that you performed among them, but hardened their necks, and in their rebellion appointed a leader to return to their bondage. But you are a God ready to pardon, gracious and merciful, slow to anger, and abounding in steadfast love, and did not forsake them. Even when they had made for themselves a calf of molten metal, and~.
 	// Yashua is His name, Emmanuel, God with us!
 	for ci := 0; ci < Nc; ci++ {
@@ -682,6 +730,13 @@ func (X *VtxGraphVM) calcTracesTo(Nc int) {
 				}
 			}
 
+			// Each edge into vi contributes the walks that arrive at vi over that edge
+			if dstEdges != nil {
+				for _, e := range dstEdges[vi.VtxID-1] {
+					e.Cycles[ci] = e.Count * Ci0[e.SrcVtxID-1]
+				}
+			}
+
 			vi_cycles_ci := Ci1[vi.VtxID-1]
 			X.traces[ci] += vi_cycles_ci
 			vi.Cycles[ci] = vi_cycles_ci
@@ -689,6 +744,60 @@ func (X *VtxGraphVM) calcTracesTo(Nc int) {
 	}
 }
 
+// edgesByDstVtx buckets this graph's edges by the vtx they flow into (zero-based indexing).
+func (X *VtxGraphVM) edgesByDstVtx() [][]*ComputeEdge {
+	Nv := len(X.vtx)
+	if cap(X.dstEdges) < Nv {
+		X.dstEdges = make([][]*ComputeEdge, Nv)
+	}
+	X.dstEdges = X.dstEdges[:Nv]
+	for i := range X.dstEdges {
+		X.dstEdges[i] = X.dstEdges[i][:0]
+	}
+	for _, e := range X.edgePool[:X.edgeCount] {
+		X.dstEdges[e.DstVtxID-1] = append(X.dstEdges[e.DstVtxID-1], e)
+	}
+	return X.dstEdges
+}
+
+// CheckedTraces is Traces but returns go2x3.ErrTracesOverflow if any of the requested Traces overflowed.
+func (X *VtxGraphVM) CheckedTraces(numTraces int) (go2x3.Traces, error) {
+	TX := X.Traces(numTraces)
+
+	// Only Traces past the magnitude bound need to be confirmed exactly
+	maxRowNorm := uint64(0)
+	for _, vj := range X.Vtx() {
+		rowNorm := uint64(0)
+		for _, e := range vj.Edges {
+			rowNorm += uint64(max(e.Count, -e.Count))
+		}
+		maxRowNorm = max(maxRowNorm, rowNorm)
+	}
+	if len(TX) > go2x3.NumExactTraces(X.VtxCount(), maxRowNorm) {
+		if _, err := X.BigTraces(len(TX)).Traces(); err != nil {
+			return TX, err
+		}
+	}
+	return TX, nil
+}
+
+// BigTraces returns the requested number of Traces (0 denotes the vertex count) computed exactly.
+func (X *VtxGraphVM) BigTraces(numTraces int) go2x3.BigTraces {
+	if X.Status < GraphStatus_Validated {
+		return nil
+	}
+
+	Xv := X.Vtx()
+	Nv := len(Xv)
+	A := make([]int64, Nv*Nv)
+	for j, vj := range Xv {
+		for _, e := range vj.Edges {
+			A[j*Nv+int(e.SrcVtxID)-1] += e.Count
+		}
+	}
+	return go2x3.BigTracesOf(A, Nv, numTraces)
+}
+
 var (
 	gLineSep = "........."
 )
@@ -924,53 +1033,110 @@ func PrintNormalizedTraces(Nv int, TX go2x3.Traces, out io.Writer) {
 	*/
 }
 
+func init() {
+	go2x3.RegisterNormalizer(&go2x3.NormalizerFunc{
+		ID:    "ceiling-240303",
+		About: "Ti / |Ti-2| (odd and even terms normalized separately, see T_ceiling_240303)",
+		Normalize: func(out []float64, TX go2x3.Traces, Nv int) []float64 {
+			for ti, Ti := range TX {
+				Tprev := int64(0)
+				if ti >= 2 {
+					Tprev = TX[ti-2]
+				}
+				out = append(out, float64(Ti)/T_ceiling_240303(Nv, ti+1, Tprev))
+			}
+			return out
+		},
+	})
+
+	// T_NormEclipse2024 is currently the same as T_FibLucas, so it is not registered separately
+	go2x3.RegisterNormalizer(&go2x3.NormalizerFunc{
+		ID:    "fib-lucas",
+		About: "ln(Ti-1 + Ti), see T_FibLucas",
+		Normalize: func(out []float64, TX go2x3.Traces, Nv int) []float64 {
+			Tn0 := int64(0)
+			for ti, Tn1 := range TX {
+				out = append(out, T_FibLucas(Nv, ti+1, Tn0, Tn1))
+				Tn0 = Tn1
+			}
+			return out
+		},
+	})
+}
+
 func (X *VtxGraphVM) PrintCycleSpectrum(numTraces int, out io.Writer) {
-	TX := X.Traces(numTraces)
+	X.printCycleSpectrum(numTraces, false, out)
+}
 
-	//Xv := X.Vtx()
-	Nc := len(TX)
+// PrintPrimitiveCycleSpectrum is PrintCycleSpectrum for primitive Traces and cycles (see go2x3.Traces.Primitive),
+// so each column only counts closed walks that are not a repeat of a shorter closed walk.
+func (X *VtxGraphVM) PrintPrimitiveCycleSpectrum(numTraces int, out io.Writer) {
+	X.printCycleSpectrum(numTraces, true, out)
+}
 
+// writeSpectrumHeader writes the column names and Traces heading a cycle spectrum.
+func writeSpectrumHeader(TX go2x3.Traces, colName byte, out io.Writer) {
 	var buf [128]byte
-
 	prOpts := PrintIntOpts{
 		MinWidth: len(gLineSep),
 	}
 
-	// Write header
-	{
-		line := buf[:0]
-		line = append(line, "                 ##        "...)
+	line := buf[:0]
+	line = append(line, "                 ##        "...)
 
-		for ti := range TX {
-			ci := ti + 1
-			if ci < 10 {
-				line = append(line, ' ')
-			}
-			line = fmt.Appendf(line, "C%d      ", ti+1)
+	for ti := range TX {
+		ci := ti + 1
+		if ci < 10 {
+			line = append(line, ' ')
 		}
+		line = fmt.Appendf(line, "%c%d      ", colName, ti+1)
+	}
 
-		// append traces
-		line = append(line, "\n                     "...)
-		for _, Ti := range TX {
-			line = AppendInt(line, Ti, prOpts)
-		}
+	// append traces
+	line = append(line, "\n                     "...)
+	for _, Ti := range TX {
+		line = AppendInt(line, Ti, prOpts)
+	}
 
-		line = append(line, "\n                     "...)
-		for i := 0; i < Nc; i++ {
-			line = append(line, gLineSep...)
-		}
-		line = append(line, '\n')
+	line = append(line, "\n                     "...)
+	for range TX {
+		line = append(line, gLineSep...)
+	}
+	line = append(line, '\n')
 
-		out.Write(line)
+	out.Write(line)
+}
+
+func (X *VtxGraphVM) printCycleSpectrum(numTraces int, primitive bool, out io.Writer) {
+	TX := X.Traces(numTraces)
+	colName := byte('C')
+	if primitive {
+		TX = TX.Primitive()
+		colName = 'P'
+	}
+
+	//Xv := X.Vtx()
+	Nc := len(TX)
+
+	var buf [128]byte
+
+	prOpts := PrintIntOpts{
+		MinWidth: len(gLineSep),
 	}
+
+	writeSpectrumHeader(TX, colName, out)
 	{
 		for _, vi := range X.Vtx() {
 			//for ni := int64(0); ni < ei.Count; ni++ {
 			{
 				line := vi.AppendDesc(buf[:0])
 				line = append(line, "  "...)
-				for i := 0; i < Nc; i++ {
-					line = AppendInt(line, vi.Cycles[i], prOpts)
+				cycles := go2x3.Traces(vi.Cycles[:Nc])
+				if primitive {
+					cycles = cycles.Primitive()
+				}
+				for _, Ci := range cycles {
+					line = AppendInt(line, Ci, prOpts)
 				}
 				line = append(line, '\n')
 				// for _, ej := range vi.Edges {
@@ -1066,7 +1232,7 @@ func (X *VtxGraphVM) setupBufs(Nc int) {
 		Nc = Nv
 	}
 
-	need := Nc + Nv*(Nc+Nc+Nc)
+	need := Nc + Nv*(Nc+Nc+Nc) + X.edgeCount*Nc
 	if len(X.calcBuf) < need {
 		X.calcBuf = make([]int64, (need+15)&^15)
 	}
@@ -1082,37 +1248,14 @@ func (X *VtxGraphVM) setupBufs(Nc int) {
 		v.Ci1, buf = chopBuf(buf, Nc)
 		v.Cycles, buf = chopBuf(buf, Nc)
 	}
+	for _, e := range X.edgePool[:X.edgeCount] {
+		e.Cycles, buf = chopBuf(buf, Nc)
+	}
 
 	if cap(X.vtxMap) < Nv {
-		X.vtxMap = make([]uint32, Nv, maxNv)
+		X.vtxMap = make([]uint32, Nv, max(Nv, maxNv))
 	} else {
 		X.vtxMap = X.vtxMap[:Nv]
 	}
 
 }
-
-type GraphEncodingOpts int
-
-func (X *VtxGraphVM) AppendGraphEncoding(io []byte, opts GraphEncodingOpts) []byte {
-	X.Canonize()
-
-	// Next steps:
-	//   - use GraphStatus to prevent redundant work
-	//   - encode canonized vtx edges (gr.Edges) as graph encoding, encoding signs LAST
-	//   - at what point are graphs redundant and can be dropped?   for example:
-	//       1. same canonization but different sign distribution (e.g. elastance of two Higgs 24+08)
-	//             -- current view: counted as a dupe
-	//       2. graphs with canonization synonyms (e.g. K8 with or without C1)
-	//             -- "normalization" is to choose the canonic form
-	//       3. graph canonized with dropped sign "deltas" (e.g. Higgs normalized to 24)
-	//              -- requires a "normalization" step to choose the canonic form
-	//              -- must prove that the canonic form is unique (1:1 mapping of existing Traces and this new form)
-
-	// The key question to know more on is when (if) dupes need to be tallied (like Griggs said),
-	//     or maybe they don't matter, can be dropped, and all the matters is the canonic form (plus state offset).
-	// That is it of any importance there are 3 proton variants and 7 neutron variants, or if they can be dropped and only the canonic form matters?
-	// Gut says the latter since who cares how many conceptual Griggs graphs are possible compared to what is the information needed to reproduce a given state.
-	//    - Is "state" what graph is implied or is it the min information needed to reproduce a particles (traces) or a traces plus ????
-
-	return nil
-}
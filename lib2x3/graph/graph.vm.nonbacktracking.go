@@ -0,0 +1,119 @@
+package graph
+
+import (
+	"github.com/fine-structures/fine.SDK/go2x3"
+)
+
+// A non-backtracking closed walk never immediately reverses the edge it just traversed.  Counting these walks
+// is trace((B)^k), where B is the Hashimoto (non-backtracking edge) matrix over the graph's directed edge halves:
+//
+//	B[e][f] = sign(f)   if e ends where f starts and f is not the reverse of e
+//
+// Each VtxEdge of count c denotes |c| edge halves of sign(c), where a loop is its own reverse (so a loop can't be
+// traversed twice in a row).  Because they forbid backtracking, these Traces separate some graphs that plain Traces do not.
+
+// nbArc is a directed unit edge half, flowing from vertex src to vertex dst (zero-based).
+type nbArc struct {
+	src, dst int
+	rev      int   // index of the reverse arc
+	sign     int64 // +1 or -1
+}
+
+// NonBacktrackingTraces returns the first numTraces non-backtracking Traces (0 denotes the vertex count), where Ti is the
+// (signed) number of non-backtracking closed walks of length i.
+//
+// This requires the graph's edges as added (i.e. before Canonize), otherwise go2x3.ErrNotSupported is returned.
+// Returns go2x3.ErrTracesOverflow if a term overflows an int64.
+func (X *VtxGraphVM) NonBacktrackingTraces(numTraces int) (go2x3.Traces, error) {
+	if X.Status < GraphStatus_Validated || X.Status >= GraphStatus_Canonized {
+		return nil, go2x3.ErrNotSupported
+	}
+	if numTraces <= 0 {
+		numTraces = X.VtxCount()
+	}
+
+	arcs := X.nonBacktrackingArcs()
+	Na := len(arcs)
+
+	// next[e] lists the arcs that may follow arc e
+	next := make([][]int, Na)
+	for ei, e := range arcs {
+		for fi, f := range arcs {
+			if f.src == e.dst && fi != e.rev {
+				next[ei] = append(next[ei], fi)
+			}
+		}
+	}
+
+	// For each starting arc, propagate the weighted count of walks ending at each arc
+	TX := make(go2x3.Traces, numTraces)
+	Wi0 := make([]int64, Na)
+	Wi1 := make([]int64, Na)
+	overflow := false
+	for start := range arcs {
+		for i := range Wi0 {
+			Wi0[i] = 0
+		}
+		Wi0[start] = 1
+
+		for ci := 0; ci < numTraces; ci++ {
+			for i := range Wi1 {
+				Wi1[i] = 0
+			}
+			for ei, We := range Wi0 {
+				if We == 0 {
+					continue
+				}
+				for _, fi := range next[ei] {
+					var ok bool
+					if Wi1[fi], ok = go2x3.MulAddChecked(Wi1[fi], We, arcs[fi].sign); !ok {
+						overflow = true
+					}
+				}
+			}
+			var ok bool
+			if TX[ci], ok = go2x3.AddChecked(TX[ci], Wi1[start]); !ok {
+				overflow = true
+			}
+			Wi0, Wi1 = Wi1, Wi0
+		}
+	}
+
+	if overflow {
+		return TX, go2x3.ErrTracesOverflow
+	}
+	return TX, nil
+}
+
+// nonBacktrackingArcs expands each edge half into unit arcs, pairing each with its reverse.
+//
+// AddEdge adds the two halves of an edge consecutively, so each non-loop half is followed by its reverse in X.edgePool.
+func (X *VtxGraphVM) nonBacktrackingArcs() []nbArc {
+	var arcs []nbArc
+	edges := X.edgePool[:X.edgeCount]
+	for i := 0; i < len(edges); i++ {
+		e := edges[i]
+		count, sign := e.Count, int64(1)
+		if count < 0 {
+			count, sign = -count, -1
+		}
+
+		src, dst := int(e.SrcVtxID)-1, int(e.DstVtxID)-1
+		if src == dst {
+			for n := int64(0); n < count; n++ {
+				arcs = append(arcs, nbArc{src: src, dst: dst, rev: len(arcs), sign: sign})
+			}
+			continue
+		}
+
+		i++ // skip the other half
+		for n := int64(0); n < count; n++ {
+			ai := len(arcs)
+			arcs = append(arcs,
+				nbArc{src: src, dst: dst, rev: ai + 1, sign: sign},
+				nbArc{src: dst, dst: src, rev: ai, sign: sign},
+			)
+		}
+	}
+	return arcs
+}
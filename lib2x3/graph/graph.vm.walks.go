@@ -0,0 +1,55 @@
+package graph
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/fine-structures/fine.SDK/go2x3"
+)
+
+// WalkMatrices returns A^1 .. A^numTraces (0 denotes the vertex count), the full walk-count matrices of this graph as added,
+// where each row and column is labeled by its (initially assigned) one-based VtxID.
+//
+// Returns go2x3.ErrTracesOverflow if a walk count overflows an int64.
+func (X *VtxGraphVM) WalkMatrices(numTraces int) ([]go2x3.WalkMatrix, error) {
+	if X.Status < GraphStatus_Validated {
+		return nil, go2x3.ErrNotSupported
+	}
+	Nv := int(math.Sqrt(float64(len(X.adj)))) // X.adj is Nv x Nv
+
+	labels := make([]string, Nv)
+	for i := range labels {
+		labels[i] = strconv.Itoa(i + 1)
+	}
+	return go2x3.WalkMatricesOf(X.adj, Nv, numTraces, labels)
+}
+
+// GroupWalkMatrices is WalkMatrices with rows and columns summed by consolidated vtx group (see Canonize), so Walks[a][b]
+// is the number of walks from any vertex of group b to any vertex of group a.  Groups are labeled by GraphID and group letter
+// as in PrintCycleSpectrum (e.g. "1.A").  The graph is canonized as needed.
+func (X *VtxGraphVM) GroupWalkMatrices(numTraces int) ([]go2x3.WalkMatrix, error) {
+	Ms, err := X.WalkMatrices(numTraces)
+	if err != nil {
+		return nil, err
+	}
+	if err := X.Canonize(); err != nil {
+		return nil, err
+	}
+
+	vtx := X.Vtx()
+	labels := make([]string, len(vtx))
+	for i, vi := range vtx {
+		labels[i] = fmt.Sprintf("%d.%c", vi.GraphID, 'A'+byte(vi.GroupID)-1)
+	}
+	if len(X.vtxGroup) != len(Ms[0].Labels) {
+		return nil, go2x3.ErrNotSupported
+	}
+
+	for k := range Ms {
+		if Ms[k], err = Ms[k].Grouped(X.vtxGroup, labels); err != nil {
+			return nil, err
+		}
+	}
+	return Ms, nil
+}
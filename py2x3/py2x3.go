@@ -5,6 +5,7 @@ package py2x2
 // license that can be found in the LICENSE file.
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -15,6 +16,7 @@ import (
 
 	"github.com/fine-structures/fine.SDK/go2x3"
 	"github.com/fine-structures/fine.SDK/lib2x3/catalog"
+	"github.com/fine-structures/fine.SDK/lib2x3/graph"
 	lib2x3 "github.com/fine-structures/fine.SDK/lib2x3/graph-legacy"
 	walker "github.com/fine-structures/fine.SDK/lib2x3/graph-walker"
 	"github.com/go-python/gpython/py"
@@ -29,6 +31,8 @@ var (
 	pyGraphStreamType = py.NewType("GraphStream", "go2x3.GraphStream")
 	pyCatalogType     = py.NewType("Catalog", "go2x3.Catalog")
 	pyWorkspaceType   = py.NewType("Workspace", "collets active session resources and catalogs")
+	pyAggregationType = py.NewType("Aggregation", "go2x3.Aggregation")
+	pyTracesType      = py.NewType("Traces", "go2x3.Traces: supports +, -, * (by an int), unary -, comparisons (lexicographic), len() and indexing")
 )
 
 // Arg 1 (int): Nv_start
@@ -45,7 +49,7 @@ func py_EnumPureParticles(module py.Object, args py.Tuple) (py.Object, error) {
 		VertexMax: int(v_max.(py.Int)),
 		Params:    "-d BackConnect.1",
 	}
-	stream := lib2x3.EnumPureParticles(opts)
+	stream := lib2x3.EnumPureParticles(getWorkspace(module).Ctx, opts)
 	return wrapGraphSteam(stream), nil
 }
 
@@ -65,6 +69,7 @@ func getGraphFromGraphObj(obj py.Object) (X pyGraph, err error) {
 
 type pyGraph struct {
 	*lib2x3.Graph
+	ws *Workspace
 }
 
 func (X pyGraph) Type() *py.Type {
@@ -83,7 +88,7 @@ func (X pyGraph) M__repr__() (py.Object, error) {
 
 func py_NewGraph(module py.Object, args py.Tuple) (py.Object, error) {
 	X := lib2x3.NewGraph(nil)
-	return py.Object(pyGraph{X}), nil
+	return py.Object(pyGraph{X, getWorkspace(module)}), nil
 }
 
 func py_Graph_NumVerts(self py.Object, args py.Tuple) (py.Object, error) {
@@ -103,15 +108,217 @@ func py_Graph_Traces(self py.Object, args py.Tuple) (py.Object, error) {
 		numTraces = int(args[0].(py.Int))
 	}
 
-	TX := X.Traces(numTraces)
+	TX, err := X.CheckedTraces(numTraces)
+	if errors.Is(err, go2x3.ErrTracesOverflow) {
+		return nil, py.ExceptionNewf(py.OverflowError, "%v (see ExactTraces)", err)
+	}
+	return pyTraces{append(go2x3.Traces{}, TX...)}, nil
+}
+
+// ExactTraces(num_traces = 0) returns this Graph's Traces as a tuple of (arbitrary-precision) ints, which never overflow.
+func py_Graph_ExactTraces(self py.Object, args py.Tuple) (py.Object, error) {
+	X := self.(pyGraph)
+	numTraces := 0
+	if err := py.LoadTuple(args, []interface{}{&numTraces}); err != nil {
+		return nil, err
+	}
+
+	TXbig := X.BigTraces(numTraces)
+	traces := make(py.Tuple, len(TXbig))
+	for i, Ti := range TXbig {
+		traces[i] = (*py.BigInt)(Ti).MaybeInt()
+	}
+	return traces, nil
+}
+
+// PrimitiveTraces(num_traces = 0) returns this Graph's primitive Traces, where Pk counts the closed walks of length k
+// that are not a repeat of a shorter closed walk.
+func py_Graph_PrimitiveTraces(self py.Object, args py.Tuple) (py.Object, error) {
+	X := self.(pyGraph)
+	numTraces := 0
+	if err := py.LoadTuple(args, []interface{}{&numTraces}); err != nil {
+		return nil, err
+	}
+	TX, err := X.CheckedTraces(numTraces)
+	if err != nil {
+		return nil, py.ExceptionNewf(py.ValueError, "%v", err)
+	}
+	return pyTraces{TX.Primitive()}, nil
+}
+
+// NonBacktrackingTraces(num_traces = 0) returns this Graph's non-backtracking Traces, where Ti counts the closed walks of length i
+// that never immediately reverse an edge.
+func py_Graph_NonBacktrackingTraces(self py.Object, args py.Tuple) (py.Object, error) {
+	X := self.(pyGraph)
+	numTraces := 0
+	if err := py.LoadTuple(args, []interface{}{&numTraces}); err != nil {
+		return nil, err
+	}
+	TX, err := X.NonBacktrackingTraces(numTraces)
+	if err != nil {
+		return nil, py.ExceptionNewf(py.ValueError, "%v", err)
+	}
+	return pyTraces{TX}, nil
+}
+
+// WalkMatrices(traces = 0, groups = False, format = "") returns this Graph's walk-count matrices A^1 .. A^N as a list of dicts
+// (keyed by "k", "labels" and "walks"), or as a CSV or JSON string if format is "csv" or "json".
+// If groups is set, rows and columns are summed by consolidated vertex group.
+func py_Graph_WalkMatrices(self py.Object, args py.Tuple, kwargs py.StringDict) (py.Object, error) {
+	X := self.(pyGraph)
+	numTraces := 0
+	byGroup := false
+	format := ""
+	py.LoadAttr(kwargs, "traces", &numTraces)
+	py.LoadAttr(kwargs, "groups", &byGroup)
+	py.LoadAttr(kwargs, "format", &format)
+
+	Ms, err := X.WalkMatrices(numTraces, byGroup)
+	if err != nil {
+		return nil, py.ExceptionNewf(py.ValueError, "%v", err)
+	}
+
+	switch format {
+	case "csv", "json":
+		str := strings.Builder{}
+		if format == "csv" {
+			err = go2x3.WriteWalkMatricesCSV(&str, Ms)
+		} else {
+			err = go2x3.WriteWalkMatricesJSON(&str, Ms)
+		}
+		if err != nil {
+			return nil, py.ExceptionNewf(py.ValueError, "%v", err)
+		}
+		return py.String(str.String()), nil
+	case "":
+	default:
+		return nil, py.ExceptionNewf(py.ValueError, "unknown walk matrix format %q", format)
+	}
+
+	list := py.NewListSized(len(Ms))
+	for mi, M := range Ms {
+		labels := make(py.Tuple, len(M.Labels))
+		for i, label := range M.Labels {
+			labels[i] = py.String(label)
+		}
+		walks := make(py.Tuple, len(M.Walks))
+		for i, row := range M.Walks {
+			pyRow := make(py.Tuple, len(row))
+			for j, Wij := range row {
+				pyRow[j] = py.Int(Wij)
+			}
+			walks[i] = pyRow
+		}
+		dict := py.NewStringDict()
+		dict["k"] = py.Int(M.K)
+		dict["labels"] = labels
+		dict["walks"] = walks
+		list.Items[mi] = dict
+	}
+	return list, nil
+}
+
+// ExtendedTraces(num_traces) returns this Graph's first num_traces Traces, computing only the first Nv Traces directly and
+// extending them by the recurrence given by its characteristic polynomial (raising OverflowError like Traces).
+func py_Graph_ExtendedTraces(self py.Object, args py.Tuple) (py.Object, error) {
+	X := self.(pyGraph)
+	numTraces := 0
+	if err := py.LoadTuple(args, []interface{}{&numTraces}); err != nil {
+		return nil, err
+	}
+
+	TX, err := go2x3.ExtendedTraces(X, numTraces)
+	if errors.Is(err, go2x3.ErrTracesOverflow) {
+		return nil, py.ExceptionNewf(py.OverflowError, "%v (see ExactTraces)", err)
+	}
+	if err != nil {
+		return nil, py.ExceptionNewf(py.ValueError, "%v", err)
+	}
+	return pyTraces{TX}, nil
+}
+
+// CharPoly() returns this Graph's characteristic polynomial coefficients (1, C1, .. Cn) as a tuple of ints.
+func py_Graph_CharPoly(self py.Object, args py.Tuple) (py.Object, error) {
+	P, err := go2x3.GraphCharPoly(self.(pyGraph))
+	if err != nil {
+		return nil, py.ExceptionNewf(py.ValueError, "%v", err)
+	}
+	return charPolyTuple(P), nil
+}
+
+// Spectrum() returns this Graph's adjacency eigenvalues as a tuple of floats in descending order.
+func py_Graph_Spectrum(self py.Object, args py.Tuple) (py.Object, error) {
+	spectrum, err := go2x3.Spectrum(self.(pyGraph))
+	if err != nil {
+		return nil, py.ExceptionNewf(py.ValueError, "%v", err)
+	}
+	return floatTuple(spectrum), nil
+}
+
+// SpectralRadius() returns the largest magnitude of this Graph's adjacency eigenvalues.
+func py_Graph_SpectralRadius(self py.Object, args py.Tuple) (py.Object, error) {
+	radius, err := go2x3.SpectralRadius(self.(pyGraph))
+	if err != nil {
+		return nil, py.ExceptionNewf(py.ValueError, "%v", err)
+	}
+	return py.Float(radius), nil
+}
 
-	N := len(TX)
-	traces := make(py.Tuple, N)
-	for i, tr := range TX {
-		traces[i] = py.Int(tr)
+func charPolyTuple(P go2x3.CharPoly) py.Tuple {
+	tuple := make(py.Tuple, len(P))
+	for i, Ci := range P {
+		tuple[i] = py.Int(Ci)
 	}
+	return tuple
+}
 
-	return py.Object(traces), nil
+func floatTuple(vals []float64) py.Tuple {
+	tuple := make(py.Tuple, len(vals))
+	for i, val := range vals {
+		tuple[i] = py.Float(val)
+	}
+	return tuple
+}
+
+// NormalizedTraces(normalizer, num_traces = 0) returns this Graph's Traces as a tuple of floats normalized by the named normalizer.
+func py_Graph_NormalizedTraces(self py.Object, args py.Tuple) (py.Object, error) {
+	X := self.(pyGraph)
+	var name string
+	numTraces := 0
+	if err := py.LoadTuple(args, []interface{}{&name, &numTraces}); err != nil {
+		return nil, err
+	}
+
+	normTX, err := go2x3.NormalizedTraces(X, numTraces, name)
+	if err != nil {
+		return nil, py.ExceptionNewf(py.ValueError, "%v", err)
+	}
+	return floatTuple(normTX), nil
+}
+
+// PrintNormalizedTraces(*normalizers, traces = 0) prints this Graph's Traces alongside each named normalization (or all if none are given).
+func py_Graph_PrintNormalizedTraces(self py.Object, args py.Tuple, kwargs py.StringDict) (py.Object, error) {
+	X := self.(pyGraph)
+	numTraces := 0
+	py.LoadAttr(kwargs, "traces", &numTraces)
+
+	names, err := getNormalizers(args)
+	if err != nil {
+		return nil, err
+	}
+	if err := go2x3.WriteNormalizedTraces(os.Stdout, X.Traces(numTraces), X.VertexCount(), names...); err != nil {
+		return nil, py.ExceptionNewf(py.ValueError, "%v", err)
+	}
+	return py.None, nil
+}
+
+// TracesNormalizers() returns a dict of each registered Traces normalizer's name and description.
+func py_TracesNormalizers(module py.Object, args py.Tuple) (py.Object, error) {
+	dict := py.NewStringDict()
+	for _, norm := range go2x3.Normalizers() {
+		dict[norm.Name()] = py.String(norm.Desc())
+	}
+	return dict, nil
 }
 
 func py_Graph_Concat(self py.Object, args py.Tuple) (py.Object, error) {
@@ -121,7 +328,7 @@ func py_Graph_Concat(self py.Object, args py.Tuple) (py.Object, error) {
 
 	for i, arg := range srcGraphs {
 		if initStr, isStr := arg.(py.String); isStr {
-			err := Xi.InitFromString(string(initStr))
+			err := Xi.InitFromExpr(string(initStr))
 			if err != nil {
 				return nil, py.ExceptionNewf(py.TypeError, "error reading part %d: %v", i, err)
 			}
@@ -139,25 +346,48 @@ func py_Graph_Concat(self py.Object, args py.Tuple) (py.Object, error) {
 	return py.Object(X), nil
 }
 
+// Encode(form = "") returns this Graph encoded in the given EncodingForm (e.g. "GraphExpr" or "EnumerationID"), where binary
+// forms are returned in hex.  Any of these can be passed back to Graph().
+func py_Graph_Encode(self py.Object, args py.Tuple) (py.Object, error) {
+	X := self.(pyGraph)
+	formName := ""
+	if err := py.LoadTuple(args, []interface{}{&formName}); err != nil {
+		return nil, err
+	}
+	form, err := graph.ParseEncodingForm(formName)
+	if err != nil {
+		return nil, py.ExceptionNewf(py.ValueError, "unknown encoding form %q", formName)
+	}
+	str, err := graph.FormatGraph(X.Graph, form)
+	if err != nil {
+		return nil, py.ExceptionNewf(py.ValueError, "%v", err)
+	}
+	return py.String(str), nil
+}
+
 func py_Graph_Stream(self py.Object, args py.Tuple) (py.Object, error) {
 	X := self.(pyGraph)
-	next := go2x3.StreamGraph(X)
+	next := go2x3.StreamGraph(X.ws.Ctx, X)
 	return wrapGraphSteam(next), nil
 }
 
 const (
-	READ_ONLY     = 0x01
-	PRIME_CATALOG = 0x04
+	READ_ONLY              = 0x01
+	PRIME_CATALOG          = 0x04
+	INDEX_NON_BACKTRACKING = 0x08
 
 	kWorkspaceAttr = "_Workspace"
 )
 
 type Workspace struct {
 	CatalogCtx go2x3.CatalogContext
+	Ctx        context.Context // cancelled when this workspace closes, stopping all its streams
+	cancel     context.CancelFunc
 	//	Stdout     *py.File
 }
 
 func (ws *Workspace) Close() {
+	ws.cancel()
 	ws.CatalogCtx.Close()
 	<-ws.CatalogCtx.Done()
 }
@@ -167,16 +397,22 @@ func (ws *Workspace) Type() *py.Type {
 }
 
 func py_GetWorkspace(module py.Object, args py.Tuple) (py.Object, error) {
+	return getWorkspace(module), nil
+}
+
+// getWorkspace returns the module's Workspace, creating it as needed.
+func getWorkspace(module py.Object) *Workspace {
 	wsObj, _ := py.GetAttrString(module, kWorkspaceAttr)
 	if wsObj == nil {
 		ws := &Workspace{
 			CatalogCtx: go2x3.NewCatalogContext(),
 			//Stdout:     module.(*py.Module).Context.Store().MustGetModule("sys").Globals["stdout"].(*py.File),
 		}
+		ws.Ctx, ws.cancel = context.WithCancel(context.Background())
 		wsObj = ws
 		py.SetAttrString(module, kWorkspaceAttr, wsObj)
 	}
-	return wsObj, nil
+	return wsObj.(*Workspace)
 }
 
 func py_Workspace_CatalogExists(self py.Object, args py.Tuple) (py.Object, error) {
@@ -212,18 +448,22 @@ func py_Workspace_OpenCatalog(self py.Object, args py.Tuple) (py.Object, error)
 	if (flags & PRIME_CATALOG) != 0 {
 		opts.NeedPrimes = true
 	}
+	if (flags & INDEX_NON_BACKTRACKING) != 0 {
+		opts.IndexBy = go2x3.IndexByNonBacktracking
+	}
 
 	cat, err := catalog.OpenCatalog(ws.CatalogCtx, opts)
 	if err != nil {
 		return nil, py.ExceptionNewf(py.RuntimeError, "%v", err)
 	}
 
-	pyCat := pyCatalog{cat}
+	pyCat := pyCatalog{cat, ws}
 	return py.Object(pyCat), nil
 }
 
 type pyCatalog struct {
 	go2x3.Catalog
+	ws *Workspace
 }
 
 func (cat pyCatalog) Type() *py.Type {
@@ -242,13 +482,13 @@ func py_Catalog_Select(self py.Object, args py.Tuple) (py.Object, error) {
 	cat := self.(pyCatalog)
 	sel := go2x3.DefaultGraphSelector
 	if len(args) > 0 {
-		err := getGraphSelector(args[0], &sel)
-		if err != nil {
+		var err error
+		if sel, err = getSelector(args[0]); err != nil {
 			return nil, err
 		}
 	}
 
-	next := go2x3.SelectFromCatalog(cat, sel)
+	next := go2x3.SelectFromCatalog(cat.ws.Ctx, cat, sel)
 	return wrapGraphSteam(next), nil
 }
 
@@ -279,9 +519,24 @@ func py_Catalog_NumPrimes(self py.Object, args py.Tuple) (py.Object, error) {
 func py_GraphStream_Go(self py.Object, args py.Tuple) (py.Object, error) {
 	stream := self.(graphStream)
 	count := stream.PullAll()
+	if err := stream.Err(); err != nil {
+		return nil, graphStreamException(err)
+	}
 	return py.Int(count), nil
 }
 
+// graphStreamException converts a GraphStream failure into a Python exception.
+// A *go2x3.GraphError carries the offending graph's expression into the message.
+func graphStreamException(err error) error {
+	return py.ExceptionNewf(py.RuntimeError, "%v", err)
+}
+
+func py_GraphStream_Close(self py.Object, args py.Tuple) (py.Object, error) {
+	stream := self.(graphStream)
+	stream.Cancel()
+	return py.None, nil
+}
+
 type echoToWriter struct {
 	stdout *os.File
 	to     io.WriteCloser
@@ -329,9 +584,17 @@ func py_GraphStream_Print(self py.Object, args py.Tuple, kwargs py.StringDict) (
 
 	py.LoadAttr(kwargs, "traces", &opts.NumTraces)
 	py.LoadAttr(kwargs, "cycles", &opts.CycleSpec)
+	py.LoadAttr(kwargs, "primitive", &opts.Primitive)
+	py.LoadAttr(kwargs, "edges", &opts.EdgeGroup)
 	py.LoadAttr(kwargs, "matrix", &opts.Matrix)
 	py.LoadAttr(kwargs, "graph", &opts.Graph)
 	py.LoadAttr(kwargs, "file", &pathname)
+	if normObj, ok := kwargs["normalize"]; ok {
+		var err error
+		if opts.Normalize, err = getNormalizers(normObj); err != nil {
+			return nil, err
+		}
+	}
 
 	// See TODO on also allowing output object instead of filename
 	writer := &echoToWriter{
@@ -347,7 +610,7 @@ func py_GraphStream_Print(self py.Object, args py.Tuple, kwargs py.StringDict) (
 		writer.to = file
 	}
 
-	next := stream.Print(writer, opts)
+	next := stream.Print(stream.Context(), writer, opts)
 	return wrapGraphSteam(next), nil
 }
 
@@ -412,7 +675,7 @@ func py_GraphStream_AddTo(self py.Object, args py.Tuple) (py.Object, error) {
 		return nil, py.ExceptionNewf(py.PermissionError, "%v", errors.New("catalog is in read-only mode"))
 	}
 
-	next := stream.AddTo(cat)
+	next := stream.AddTo(stream.Context(), cat)
 	return wrapGraphSteam(next), nil
 }
 
@@ -421,38 +684,258 @@ func py_GraphStream_DropDupes(self py.Object, args py.Tuple) (py.Object, error)
 
 	// Create a memory resident catalog that get auto-closed when the stream closes
 	cat := lib2x3.NewDropDupes(lib2x3.DropDupeOpts{})
-	next := stream.AddTo(cat)
+	next := stream.AddTo(stream.Context(), cat)
 	return wrapGraphSteam(next), nil
 }
 
-func py_GraphStream_Canonize(self py.Object, args py.Tuple) (py.Object, error) {
+// getStageOpts reads the optional "workers" and "ordered" kwargs of a parallel stage.
+func getStageOpts(kwargs py.StringDict) go2x3.StageOpts {
+	opts := go2x3.StageOpts{}
+	ordered := true
+	py.LoadAttr(kwargs, "workers", &opts.Workers)
+	py.LoadAttr(kwargs, "ordered", &ordered)
+	opts.Unordered = !ordered
+	return opts
+}
+
+func py_GraphStream_Canonize(self py.Object, args py.Tuple, kwargs py.StringDict) (py.Object, error) {
 	stream := self.(graphStream)
 	normalize := false
 	err := py.LoadTuple(args, []interface{}{&normalize})
 	if err != nil {
 		return nil, err
 	}
-	next := stream.Canonize(normalize)
+	next := stream.Canonize(stream.Context(), normalize, getStageOpts(kwargs))
 	return wrapGraphSteam(next), nil
 }
 
-func py_GraphStream_Select(self py.Object, args py.Tuple) (py.Object, error) {
-	sel := go2x3.DefaultGraphSelector
-	err := getGraphSelector(args[0], &sel)
+func py_GraphStream_EdgeGroupDual(self py.Object, args py.Tuple, kwargs py.StringDict) (py.Object, error) {
+	stream := self.(graphStream)
+	next := stream.EdgeGroupDual(stream.Context(), getStageOpts(kwargs))
+	return wrapGraphSteam(next), nil
+}
+
+func py_GraphStream_Select(self py.Object, args py.Tuple, kwargs py.StringDict) (py.Object, error) {
+	if len(args) != 1 {
+		return nil, py.ExceptionNewf(py.TypeError, "Select takes a GraphSelector or query string")
+	}
+	sel, err := getSelector(args[0])
+	if err != nil {
+		return nil, err
+	}
+	if primesObj, ok := kwargs["primes"]; ok {
+		if sel.Primes, err = getPrimeTester(primesObj); err != nil {
+			return nil, err
+		}
+	}
+	stream := self.(graphStream)
+	next := stream.SelectFromStream(stream.Context(), sel, getStageOpts(kwargs))
+	return wrapGraphSteam(next), nil
+}
+
+func py_GraphStream_PrefetchTraces(self py.Object, args py.Tuple, kwargs py.StringDict) (py.Object, error) {
+	stream := self.(graphStream)
+	numTraces := 0
+	err := py.LoadTuple(args, []interface{}{&numTraces})
 	if err != nil {
 		return nil, err
 	}
+	next := stream.PrefetchTraces(stream.Context(), numTraces, getStageOpts(kwargs))
+	return wrapGraphSteam(next), nil
+}
+
+func py_GraphStream_Tee(self py.Object, args py.Tuple) (py.Object, error) {
 	stream := self.(graphStream)
-	next := stream.SelectFromStream(sel)
+	n := 2
+	err := py.LoadTuple(args, []interface{}{&n})
+	if err != nil {
+		return nil, err
+	}
+	branches, err := stream.Tee(stream.Context(), n)
+	if err != nil {
+		return nil, py.ExceptionNewf(py.ValueError, "%v", err)
+	}
+	out := make(py.Tuple, n)
+	for i, branch := range branches {
+		out[i] = wrapGraphSteam(branch)
+	}
+	return out, nil
+}
+
+// getGraphStreams returns self followed by each GraphStream in args.
+func getGraphStreams(self py.Object, args py.Tuple) ([]*go2x3.GraphStream, error) {
+	streams := []*go2x3.GraphStream{self.(graphStream).GraphStream}
+	for i, arg := range args {
+		src, ok := arg.(graphStream)
+		if !ok {
+			return nil, py.ExceptionNewf(py.TypeError, "arg %d: expected GraphStream object (got %v)", i+1, arg.Type().Name)
+		}
+		streams = append(streams, src.GraphStream)
+	}
+	return streams, nil
+}
+
+func py_GraphStream_Merge(self py.Object, args py.Tuple) (py.Object, error) {
+	streams, err := getGraphStreams(self, args)
+	if err != nil {
+		return nil, err
+	}
+	next := go2x3.Merge(streams[0].Context(), streams...)
+	return wrapGraphSteam(next), nil
+}
+
+func py_GraphStream_Concat(self py.Object, args py.Tuple) (py.Object, error) {
+	streams, err := getGraphStreams(self, args)
+	if err != nil {
+		return nil, err
+	}
+	next := go2x3.Concat(streams[0].Context(), streams...)
 	return wrapGraphSteam(next), nil
 }
 
+func py_GraphStream_Limit(self py.Object, args py.Tuple) (py.Object, error) {
+	stream := self.(graphStream)
+	var n int
+	if err := py.LoadTuple(args, []interface{}{&n}); err != nil {
+		return nil, err
+	}
+	next := stream.Limit(stream.Context(), n)
+	return wrapGraphSteam(next), nil
+}
+
+func py_GraphStream_Skip(self py.Object, args py.Tuple) (py.Object, error) {
+	stream := self.(graphStream)
+	var n int
+	if err := py.LoadTuple(args, []interface{}{&n}); err != nil {
+		return nil, err
+	}
+	next := stream.Skip(stream.Context(), n)
+	return wrapGraphSteam(next), nil
+}
+
+func py_GraphStream_Sample(self py.Object, args py.Tuple) (py.Object, error) {
+	stream := self.(graphStream)
+	var rate float64
+	var seed int64
+	if err := py.LoadTuple(args, []interface{}{&rate, &seed}); err != nil {
+		return nil, err
+	}
+	if rate < 0 || rate > 1 {
+		return nil, py.ExceptionNewf(py.ValueError, "sample rate must be in 0..1 (got %v)", rate)
+	}
+	next := stream.Sample(stream.Context(), rate, uint64(seed))
+	return wrapGraphSteam(next), nil
+}
+
+// DedupeBy(key = "state", traces = 0) drops graphs whose key was already seen, where key is one of:
+//
+//	"traces" - the graph's first N traces (traces kwarg; 0 denotes the vertex count)
+//	"state"  - the graph's traces and state encoding (same as DropDupes)
+//	"graph"  - the graph's expression
+func py_GraphStream_DedupeBy(self py.Object, args py.Tuple, kwargs py.StringDict) (py.Object, error) {
+	stream := self.(graphStream)
+	keyName := "state"
+	numTraces := 0
+	py.LoadTuple(args, []interface{}{&keyName})
+	py.LoadAttr(kwargs, "traces", &numTraces)
+
+	var keyFunc go2x3.StateKeyFunc
+	switch keyName {
+	case "traces":
+		keyFunc = go2x3.KeyByTraces(numTraces)
+	case "state":
+		keyFunc = go2x3.KeyByState
+	case "graph":
+		keyFunc = go2x3.KeyByGraphExpr
+	default:
+		return nil, py.ExceptionNewf(py.ValueError, "unknown dedupe key %q (expected \"traces\", \"state\" or \"graph\")", keyName)
+	}
+	next := stream.DedupeBy(stream.Context(), keyFunc)
+	return wrapGraphSteam(next), nil
+}
+
+type pyAggregation struct {
+	*go2x3.Aggregation
+}
+
+func (agg pyAggregation) Type() *py.Type {
+	return pyAggregationType
+}
+
+// Aggregate(*fields, traces = 0, distinct = False) returns (GraphStream, Aggregation), where:
+//
+//	fields   - names of GraphInfo fields to group by ("parts", "verts", "neg_edges", ...) or "traces"
+//	traces   - number of traces used when grouping by traces or counting distinct traces (0 denotes the vertex count)
+//	distinct - if set, the number of distinct traces within each group is also counted
+func py_GraphStream_Aggregate(self py.Object, args py.Tuple, kwargs py.StringDict) (py.Object, error) {
+	stream := self.(graphStream)
+	opts := go2x3.AggregateOpts{}
+	for _, arg := range args {
+		name, ok := arg.(py.String)
+		if !ok {
+			return nil, py.ExceptionNewf(py.TypeError, "expected field name (got %v)", arg.Type().Name)
+		}
+		field, ok := go2x3.AggregateFieldByName(string(name))
+		if !ok {
+			return nil, py.ExceptionNewf(py.ValueError, "unknown aggregate field %q", string(name))
+		}
+		opts.GroupBy = append(opts.GroupBy, field)
+	}
+	py.LoadAttr(kwargs, "traces", &opts.NumTraces)
+	py.LoadAttr(kwargs, "distinct", &opts.DistinctTraces)
+
+	next, agg := stream.Aggregate(stream.Context(), opts)
+	return py.Tuple{wrapGraphSteam(next), pyAggregation{agg}}, nil
+}
+
+// Rows() returns a list of dicts, one per group, keyed by field name plus "count" (and "distinct").
+func py_Aggregation_Rows(self py.Object, args py.Tuple) (py.Object, error) {
+	agg := self.(pyAggregation)
+	rows := agg.Rows()
+	list := py.NewListSized(len(rows))
+	for ri, row := range rows {
+		dict := py.NewStringDict()
+		for i, field := range agg.Opts.GroupBy {
+			if field == go2x3.ByTraces {
+				traces := make(py.Tuple, len(row.Traces))
+				for j, TXj := range row.Traces {
+					traces[j] = py.Int(TXj)
+				}
+				dict[field.String()] = traces
+			} else {
+				dict[field.String()] = py.Int(row.Key[i])
+			}
+		}
+		dict["count"] = py.Int(row.Count)
+		if agg.Opts.DistinctTraces {
+			dict["distinct"] = py.Int(row.DistinctTraces)
+		}
+		list.Items[ri] = dict
+	}
+	return list, nil
+}
+
+// Print(label = "") writes the aggregation as a table to stdout.
+func py_Aggregation_Print(self py.Object, args py.Tuple) (py.Object, error) {
+	agg := self.(pyAggregation)
+	var label string
+	py.LoadTuple(args, []interface{}{&label})
+	if label != "" {
+		fmt.Fprintf(os.Stdout, "%s\n", label)
+	}
+	if err := agg.WriteTable(os.Stdout); err != nil {
+		return nil, py.ExceptionNewf(py.OSError, "%v", err)
+	}
+	return py.None, nil
+}
+
 func py_GraphStream_PermuteEdgeSigns(self py.Object, args py.Tuple) (py.Object, error) {
 	ch00 := self.(graphStream)
-	ch01 := ch00.PermuteVtxSigns()
+	ctx := ch00.Context()
+	ch01 := ch00.PermuteVtxSigns(ctx)
 	cat := lib2x3.NewDropDupes(lib2x3.DropDupeOpts{})
-	ch02 := ch01.AddTo(cat)
-	ch03 := ch02.PermuteEdgeSigns()
+	ch02 := ch01.AddTo(ctx, cat)
+	ch03 := ch02.PermuteEdgeSigns(ctx)
 	return wrapGraphSteam(ch03), nil
 }
 
@@ -461,22 +944,56 @@ func init() {
 	/////////////////////////////////
 	// Graph
 	{
-		pyGraphType.Dict["Traces"] = py.MustNewMethod("Traces", py_Graph_Traces, 0, "exports this Graph's Traces as a bytes object")
+		pyGraphType.Dict["Traces"] = py.MustNewMethod("Traces", py_Graph_Traces, 0, "returns this Graph's Traces (raising OverflowError if they overflow int64)")
+		pyGraphType.Dict["ExactTraces"] = py.MustNewMethod("ExactTraces", py_Graph_ExactTraces, 0, "returns this Graph's Traces as a tuple of exact ints (which never overflow)")
 		pyGraphType.Dict["NumVerts"] = py.MustNewMethod("NumVerts", py_Graph_NumVerts, 0, "")
 		pyGraphType.Dict["NumParts"] = py.MustNewMethod("NumParts", py_Graph_NumParts, 0, "")
+		pyGraphType.Dict["PrimitiveTraces"] = py.MustNewMethod("PrimitiveTraces", py_Graph_PrimitiveTraces, 0, "returns this Graph's primitive (Möbius-inverted) Traces")
+		pyGraphType.Dict["NonBacktrackingTraces"] = py.MustNewMethod("NonBacktrackingTraces", py_Graph_NonBacktrackingTraces, 0, "returns this Graph's non-backtracking Traces")
+		pyGraphType.Dict["WalkMatrices"] = py.MustNewMethod("WalkMatrices", py_Graph_WalkMatrices, 0, "returns this Graph's walk-count matrices A^k (per vertex or per vertex group) as a list of dicts, CSV or JSON")
+		pyGraphType.Dict["ExtendedTraces"] = py.MustNewMethod("ExtendedTraces", py_Graph_ExtendedTraces, 0, "returns this Graph's first N Traces, extended by recurrence from its first Nv Traces")
+		pyGraphType.Dict["CharPoly"] = py.MustNewMethod("CharPoly", py_Graph_CharPoly, 0, "returns this Graph's characteristic polynomial coefficients (1, C1, .. Cn) as a tuple of ints")
+		pyGraphType.Dict["Spectrum"] = py.MustNewMethod("Spectrum", py_Graph_Spectrum, 0, "returns this Graph's adjacency eigenvalues in descending order")
+		pyGraphType.Dict["SpectralRadius"] = py.MustNewMethod("SpectralRadius", py_Graph_SpectralRadius, 0, "returns the largest magnitude of this Graph's adjacency eigenvalues")
+		pyGraphType.Dict["NormalizedTraces"] = py.MustNewMethod("NormalizedTraces", py_Graph_NormalizedTraces, 0, "returns this Graph's Traces as a tuple of floats normalized by the named normalizer")
+		pyGraphType.Dict["PrintNormalizedTraces"] = py.MustNewMethod("PrintNormalizedTraces", py_Graph_PrintNormalizedTraces, 0, "prints this Graph's Traces alongside each named normalization")
 		pyGraphType.Dict["Concat"] = py.MustNewMethod("Concat", py_Graph_Concat, 0, "")
+		pyGraphType.Dict["Encode"] = py.MustNewMethod("Encode", py_Graph_Encode, 0, "returns this Graph encoded in the given EncodingForm (binary forms in hex)")
 		pyGraphType.Dict["Stream"] = py.MustNewMethod("Stream", py_Graph_Stream, 0, "")
 	}
 
 	/////////////////////////////////
 	// Catalog
 	{
-		pyCatalogType.Dict["Select"] = py.MustNewMethod("Select", py_Catalog_Select, 0, "")
+		pyCatalogType.Dict["Select"] = py.MustNewMethod("Select", py_Catalog_Select, 0, "selects graphs meeting the given GraphSelector or query string")
 		pyCatalogType.Dict["NumTraces"] = py.MustNewMethod("NumTraces", py_Catalog_NumTraces, 0, "")
 		pyCatalogType.Dict["NumPrimes"] = py.MustNewMethod("NumPrimes", py_Catalog_NumPrimes, 0, "")
 		pyCatalogType.Dict["Close"] = py.MustNewMethod("Close", py_Catalog_Close, 0, "")
 	}
 
+	/////////////////////////////////
+	// Traces
+	{
+		pyTracesType.Dict["NegateOdd"] = py.MustNewMethod("NegateOdd", py_Traces_NegateOdd, 0, "returns these Traces with odd terms negated (the antiparticle's Traces)")
+		pyTracesType.Dict["Dot"] = py.MustNewMethod("Dot", py_Traces_Dot, 0, "returns the dot product with the given Traces")
+		pyTracesType.Dict["CompareMagnitude"] = py.MustNewMethod("CompareMagnitude", py_Traces_CompareMagnitude, 0, "compares the absolute value of each term with the given Traces (-1, 0, +1)")
+		pyTracesType.Dict["IsZero"] = py.MustNewMethod("IsZero", py_Traces_IsZero, 0, "returns True if every term is 0")
+		pyTracesType.Dict["IsBoson"] = py.MustNewMethod("IsBoson", py_Traces_IsBoson, 0, "returns True if every odd term is 0")
+		pyTracesType.Dict["CharPoly"] = py.MustNewMethod("CharPoly", py_Traces_CharPoly, 0, "returns the characteristic polynomial coefficients (1, C1, .. Cn) of a graph having these Traces, where n is the number of Traces")
+		pyTracesType.Dict["Primitive"] = py.MustNewMethod("Primitive", py_Traces_Primitive, 0, "returns the primitive (Möbius-inverted) Traces, where Pk counts closed walks that are not a repeat of a shorter closed walk")
+		pyTracesType.Dict["FromPrimitive"] = py.MustNewMethod("FromPrimitive", py_Traces_FromPrimitive, 0, "returns the Traces whose primitive Traces are these (the inverse of Primitive)")
+		pyTracesType.Dict["Recurrence"] = py.MustNewMethod("Recurrence", py_Traces_Recurrence, 0, "returns the coefficients (1, C1, .. Cd) of the shortest linear recurrence these Traces satisfy (Berlekamp-Massey)")
+		pyTracesType.Dict["Extend"] = py.MustNewMethod("Extend", py_Traces_Extend, 0, "returns these Traces extended to the given length by the shortest linear recurrence they satisfy")
+		pyTracesType.Dict["String"] = py.MustNewMethod("String", py_Traces_String, 0, "returns these Traces in parseable text form, e.g. \"5,13,35\"")
+	}
+
+	/////////////////////////////////
+	// Aggregation
+	{
+		pyAggregationType.Dict["Rows"] = py.MustNewMethod("Rows", py_Aggregation_Rows, 0, "returns each aggregation row as a dict")
+		pyAggregationType.Dict["Print"] = py.MustNewMethod("Print", py_Aggregation_Print, 0, "prints the aggregation as a table")
+	}
+
 	/////////////////////////////////
 	// Workspace
 	{
@@ -488,13 +1005,24 @@ func init() {
 	// GraphStream
 	{
 		pyGraphStreamType.Dict["Go"] = py.MustNewMethod("Go", py_GraphStream_Go, 0, "counts the number of graphs output from the GraphStream")
+		pyGraphStreamType.Dict["Close"] = py.MustNewMethod("Close", py_GraphStream_Close, 0, "cancels the GraphStream and everything upstream of it")
 		pyGraphStreamType.Dict["Print"] = py.MustNewMethod("Print", py_GraphStream_Print, 0, "prints each graph from the GraphStream")
 		// pyGraphStreamType.Dict["PullGraph"] = py.MustNewMethod("PullGraph", py_GraphStream_PullGraph, 0, "")
 		// pyGraphStreamType.Dict["PushGraph"] = py.MustNewMethod("PushGraph", py_GraphStream_PushGraph, 0, "")
 		pyGraphStreamType.Dict["AddTo"] = py.MustNewMethod("AddTo", py_GraphStream_AddTo, 0, "")
 		pyGraphStreamType.Dict["Canonize"] = py.MustNewMethod("Canonize", py_GraphStream_Canonize, 0, "")
 		pyGraphStreamType.Dict["DropDupes"] = py.MustNewMethod("DropDupes", py_GraphStream_DropDupes, 0, "")
-		pyGraphStreamType.Dict["Select"] = py.MustNewMethod("Select", py_GraphStream_Select, 0, "")
+		pyGraphStreamType.Dict["Select"] = py.MustNewMethod("Select", py_GraphStream_Select, 0, "emits graphs meeting the given GraphSelector or query string (e.g. \"v in 4..8 && prime && T1==5\")")
+		pyGraphStreamType.Dict["EdgeGroupDual"] = py.MustNewMethod("EdgeGroupDual", py_GraphStream_EdgeGroupDual, 0, "replaces each graph with its edge group dual (edge groups become vertices joined by the number of vertices they share)")
+		pyGraphStreamType.Dict["PrefetchTraces"] = py.MustNewMethod("PrefetchTraces", py_GraphStream_PrefetchTraces, 0, "computes each graph's traces in advance (optionally across workers)")
+		pyGraphStreamType.Dict["Limit"] = py.MustNewMethod("Limit", py_GraphStream_Limit, 0, "emits the first N graphs and then stops the stream")
+		pyGraphStreamType.Dict["Skip"] = py.MustNewMethod("Skip", py_GraphStream_Skip, 0, "drops the first N graphs")
+		pyGraphStreamType.Dict["Sample"] = py.MustNewMethod("Sample", py_GraphStream_Sample, 0, "emits each graph with the given probability (rate, seed)")
+		pyGraphStreamType.Dict["DedupeBy"] = py.MustNewMethod("DedupeBy", py_GraphStream_DedupeBy, 0, "drops graphs whose key was already seen (\"traces\", \"state\" or \"graph\")")
+		pyGraphStreamType.Dict["Aggregate"] = py.MustNewMethod("Aggregate", py_GraphStream_Aggregate, 0, "tallies graphs grouped by the given fields, returning (GraphStream, Aggregation)")
+		pyGraphStreamType.Dict["Tee"] = py.MustNewMethod("Tee", py_GraphStream_Tee, 0, "duplicates this GraphStream into N GraphStreams (default 2)")
+		pyGraphStreamType.Dict["Merge"] = py.MustNewMethod("Merge", py_GraphStream_Merge, 0, "combines this GraphStream with the given GraphStreams (in arrival order)")
+		pyGraphStreamType.Dict["Concat"] = py.MustNewMethod("Concat", py_GraphStream_Concat, 0, "appends the given GraphStreams to this GraphStream (in turn)")
 		pyGraphStreamType.Dict["PermuteEdgeSigns"] = py.MustNewMethod("PermuteEdgeSigns", py_GraphStream_PermuteEdgeSigns, 0, "")
 
 	}
@@ -502,6 +1030,8 @@ func init() {
 	{
 		methods := []*py.Method{
 			py.MustNewMethod("NewGraph", py_NewGraph, 0, ""),
+			py.MustNewMethod("TracesNormalizers", py_TracesNormalizers, 0, "returns a dict of the available Traces normalizers and their descriptions"),
+			py.MustNewMethod("Traces", py_NewTraces, 0, "returns a Traces from a str (e.g. \"5,13,35\"), a tuple or list of ints, or the given ints"),
 			//py.MustNewMethod("GraphStream", py_NewGraphStream, 0, ""),
 			py.MustNewMethod("EnumPureParticles", py_EnumPureParticles, 0, ""),
 			py.MustNewMethod("GetWorkspace", py_GetWorkspace, 0, ""),
@@ -608,6 +1138,76 @@ func exportGraphInfo(graphInfo py.Object) go2x3.GraphInfo {
 	return info
 }
 
+// getSelector returns the GraphSelector given by either a query string (see go2x3.ParseQuery) or a GraphSelector.
+func getSelector(obj py.Object) (go2x3.GraphSelector, error) {
+	if queryStr, ok := obj.(py.String); ok {
+		sel, err := go2x3.ParseQuery(string(queryStr))
+		if err != nil {
+			return sel, py.ExceptionNewf(py.ValueError, "%v", err)
+		}
+		return sel, nil
+	}
+
+	sel := go2x3.DefaultGraphSelector
+	err := getGraphSelector(obj, &sel)
+	return sel, err
+}
+
+// getPrimeTester returns the PrimeTester of the given prime Catalog (or nil if None).
+func getPrimeTester(primesObj py.Object) (go2x3.PrimeTester, error) {
+	if primesObj == py.None {
+		return nil, nil
+	}
+	if catObj, err := py.GetAttrString(primesObj, "_cat"); err == nil {
+		primesObj = catObj
+	}
+	cat, ok := primesObj.(pyCatalog)
+	if !ok {
+		return nil, py.ExceptionNewf(py.TypeError, "'primes' must be a Catalog (got %v)", primesObj.Type().Name)
+	}
+	primes, ok := cat.Catalog.(go2x3.PrimeTester)
+	if !ok {
+		return nil, py.ExceptionNewf(py.TypeError, "'primes' catalog can't prime test")
+	}
+	return primes, nil
+}
+
+// getStrings returns the given str, or each str in the given list or tuple.
+func getStrings(obj py.Object, what string) ([]string, error) {
+	if str, ok := obj.(py.String); ok {
+		return []string{string(str)}, nil
+	}
+
+	var strs []string
+	var itemErr error
+	if err := py.Iterate(obj, func(item py.Object) bool {
+		str, ok := item.(py.String)
+		if !ok {
+			itemErr = py.ExceptionNewf(py.TypeError, "each %s must be a str (got %v)", what, item.Type().Name)
+			return true
+		}
+		strs = append(strs, string(str))
+		return false
+	}); err != nil {
+		return nil, err
+	}
+	return strs, itemErr
+}
+
+// getNormalizers returns the given normalizer names, checking that each is registered.
+func getNormalizers(obj py.Object) ([]string, error) {
+	names, err := getStrings(obj, "normalizer")
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		if _, err := go2x3.GetNormalizer(name); err != nil {
+			return nil, py.ExceptionNewf(py.ValueError, "%v", err)
+		}
+	}
+	return names, nil
+}
+
 func getGraphSelector(graph_selector py.Object, sel *go2x3.GraphSelector) error {
 
 	info, err := py.GetAttrString(graph_selector, "min")
@@ -638,6 +1238,27 @@ func getGraphSelector(graph_selector py.Object, sel *go2x3.GraphSelector) error
 		return err
 	}
 
+	// "primes" is optional: a prime Catalog used to prime test graphs on a GraphStream
+	if primesObj, _ := py.GetAttrString(graph_selector, "primes"); primesObj != nil {
+		if sel.Primes, err = getPrimeTester(primesObj); err != nil {
+			return err
+		}
+	}
+
+	// "predicates" is optional: a list of Traces query terms (see go2x3.ParseTracesPredicates)
+	if predsObj, _ := py.GetAttrString(graph_selector, "predicates"); predsObj != nil && predsObj != py.None {
+		terms, err := getStrings(predsObj, "predicate")
+		if err != nil {
+			return err
+		}
+		if len(terms) > 0 {
+			sel.Predicates, err = go2x3.ParseTracesPredicates(strings.Join(terms, " && "))
+			if err != nil {
+				return py.ExceptionNewf(py.ValueError, "%v", err)
+			}
+		}
+	}
+
 	if sel.Factor && (sel.SelectPrimes || sel.UniqueTraces || sel.SelectBosons) {
 		return py.ExceptionNewf(py.ValueError, "%v", errors.New("'factor' mode can't be used with other modes"))
 	}
@@ -646,8 +1267,6 @@ func getGraphSelector(graph_selector py.Object, sel *go2x3.GraphSelector) error
 	if err != nil {
 		return err
 	}
-	getGraphFromGraphObj(tracesObj)
-
 	switch tracesObj.(type) {
 	// case py.Tuple, *py.List:
 	// 	sel.Traces, err = py.LoadIntsFromList(tracesObj)
@@ -656,6 +1275,19 @@ func getGraphSelector(graph_selector py.Object, sel *go2x3.GraphSelector) error
 	// 	}
 	case py.NoneType:
 		sel.Traces = nil
+	case pyTraces:
+		TX := tracesObj.(pyTraces).Traces
+		sel.Min.NumVertex = byte(len(TX))
+		sel.Max.NumVertex = byte(len(TX))
+		sel.Traces = TX
+	case py.String:
+		X := lib2x3.NewGraph(nil)
+		if err := X.InitFromExpr(string(tracesObj.(py.String))); err != nil {
+			return py.ExceptionNewf(py.ValueError, "%v", err)
+		}
+		sel.Min.NumVertex = byte(X.VertexCount())
+		sel.Max.NumVertex = byte(X.VertexCount())
+		sel.Traces = X
 	default:
 		X, err := getGraphFromGraphObj(tracesObj)
 		if err != nil {
@@ -0,0 +1,265 @@
+package py2x2
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/fine-structures/fine.SDK/go2x3"
+	"github.com/go-python/gpython/py"
+)
+
+// pyTraces is a go2x3.Traces exported to Python, printed in tuple form, e.g. "(5, 13, 35)".
+type pyTraces struct {
+	go2x3.Traces
+}
+
+func (TX pyTraces) Type() *py.Type {
+	return pyTracesType
+}
+
+func (TX pyTraces) M__str__() (py.Object, error) {
+	var str strings.Builder
+	str.WriteByte('(')
+	for i, Ti := range TX.Traces {
+		if i > 0 {
+			str.WriteString(", ")
+		}
+		str.WriteString(strconv.FormatInt(Ti, 10))
+	}
+	if len(TX.Traces) == 1 {
+		str.WriteByte(',')
+	}
+	str.WriteByte(')')
+	return py.String(str.String()), nil
+}
+
+func (TX pyTraces) M__repr__() (py.Object, error) {
+	return TX.M__str__()
+}
+
+func (TX pyTraces) M__len__() (py.Object, error) {
+	return py.Int(len(TX.Traces)), nil
+}
+
+func (TX pyTraces) M__bool__() (py.Object, error) {
+	return py.NewBool(!TX.IsZero()), nil
+}
+
+func (TX pyTraces) tuple() py.Tuple {
+	tuple := make(py.Tuple, len(TX.Traces))
+	for i, Ti := range TX.Traces {
+		tuple[i] = py.Int(Ti)
+	}
+	return tuple
+}
+
+func (TX pyTraces) M__getitem__(key py.Object) (py.Object, error) {
+	return TX.tuple().M__getitem__(key)
+}
+
+func (TX pyTraces) M__iter__() (py.Object, error) {
+	return py.NewIterator(TX.tuple()), nil
+}
+
+func (TX pyTraces) M__add__(other py.Object) (py.Object, error) {
+	if TY, ok := getTraces(other); ok {
+		return pyTraces{TX.Add(TY)}, nil
+	}
+	return py.NotImplemented, nil
+}
+
+func (TX pyTraces) M__radd__(other py.Object) (py.Object, error) {
+	return TX.M__add__(other)
+}
+
+func (TX pyTraces) M__sub__(other py.Object) (py.Object, error) {
+	if TY, ok := getTraces(other); ok {
+		return pyTraces{TX.Sub(TY)}, nil
+	}
+	return py.NotImplemented, nil
+}
+
+func (TX pyTraces) M__rsub__(other py.Object) (py.Object, error) {
+	if TY, ok := getTraces(other); ok {
+		return pyTraces{TY.Sub(TX.Traces)}, nil
+	}
+	return py.NotImplemented, nil
+}
+
+func (TX pyTraces) M__mul__(other py.Object) (py.Object, error) {
+	if k, ok := other.(py.Int); ok {
+		return pyTraces{TX.Scale(int64(k))}, nil
+	}
+	return py.NotImplemented, nil
+}
+
+func (TX pyTraces) M__rmul__(other py.Object) (py.Object, error) {
+	return TX.M__mul__(other)
+}
+
+func (TX pyTraces) M__neg__() (py.Object, error) {
+	return pyTraces{TX.Scale(-1)}, nil
+}
+
+func (TX pyTraces) M__pos__() (py.Object, error) {
+	return TX, nil
+}
+
+func (TX pyTraces) compare(other py.Object, test func(c int) bool) (py.Object, error) {
+	if TY, ok := getTraces(other); ok {
+		return py.NewBool(test(TX.Compare(TY))), nil
+	}
+	return py.NotImplemented, nil
+}
+
+func (TX pyTraces) M__eq__(other py.Object) (py.Object, error) {
+	return TX.compare(other, func(c int) bool { return c == 0 })
+}
+
+func (TX pyTraces) M__ne__(other py.Object) (py.Object, error) {
+	return TX.compare(other, func(c int) bool { return c != 0 })
+}
+
+func (TX pyTraces) M__lt__(other py.Object) (py.Object, error) {
+	return TX.compare(other, func(c int) bool { return c < 0 })
+}
+
+func (TX pyTraces) M__le__(other py.Object) (py.Object, error) {
+	return TX.compare(other, func(c int) bool { return c <= 0 })
+}
+
+func (TX pyTraces) M__gt__(other py.Object) (py.Object, error) {
+	return TX.compare(other, func(c int) bool { return c > 0 })
+}
+
+func (TX pyTraces) M__ge__(other py.Object) (py.Object, error) {
+	return TX.compare(other, func(c int) bool { return c >= 0 })
+}
+
+// getTraces returns the Traces given by a Traces, a tuple or list of ints, or a str (see go2x3.ParseTraces).
+func getTraces(obj py.Object) (go2x3.Traces, bool) {
+	switch obj := obj.(type) {
+	case pyTraces:
+		return obj.Traces, true
+	case py.Tuple, *py.List:
+		ints, err := py.LoadIntsFromList(obj)
+		if err != nil {
+			return nil, false
+		}
+		return go2x3.Traces(ints), true
+	case py.String:
+		TX, err := go2x3.ParseTraces(string(obj))
+		return TX, err == nil
+	}
+	return nil, false
+}
+
+func getTracesArg(args py.Tuple, method string) (go2x3.Traces, error) {
+	if len(args) != 1 {
+		return nil, py.ExceptionNewf(py.TypeError, "%s takes one Traces", method)
+	}
+	TX, ok := getTraces(args[0])
+	if !ok {
+		return nil, py.ExceptionNewf(py.TypeError, "%s expects a Traces, tuple, list or str (got %v)", method, args[0].Type().Name)
+	}
+	return TX, nil
+}
+
+// py_NewTraces returns a Traces from a str (e.g. "5,13,35"), a tuple or list of ints, another Traces, or the given ints.
+func py_NewTraces(module py.Object, args py.Tuple) (py.Object, error) {
+	if len(args) == 1 {
+		if str, ok := args[0].(py.String); ok {
+			TX, err := go2x3.ParseTraces(string(str))
+			if err != nil {
+				return nil, py.ExceptionNewf(py.ValueError, "%v", err)
+			}
+			return pyTraces{TX}, nil
+		}
+		if TX, ok := getTraces(args[0]); ok {
+			return pyTraces{append(go2x3.Traces{}, TX...)}, nil
+		}
+	}
+
+	TX := make(go2x3.Traces, len(args))
+	for i, arg := range args {
+		Ti, err := py.GetInt(arg)
+		if err != nil {
+			return nil, err
+		}
+		TX[i] = int64(Ti)
+	}
+	return pyTraces{TX}, nil
+}
+
+func py_Traces_NegateOdd(self py.Object, args py.Tuple) (py.Object, error) {
+	return pyTraces{self.(pyTraces).NegateOdd()}, nil
+}
+
+func py_Traces_Dot(self py.Object, args py.Tuple) (py.Object, error) {
+	TY, err := getTracesArg(args, "Dot")
+	if err != nil {
+		return nil, err
+	}
+	return py.Int(self.(pyTraces).Dot(TY)), nil
+}
+
+func py_Traces_CompareMagnitude(self py.Object, args py.Tuple) (py.Object, error) {
+	TY, err := getTracesArg(args, "CompareMagnitude")
+	if err != nil {
+		return nil, err
+	}
+	return py.Int(self.(pyTraces).CompareMagnitude(TY)), nil
+}
+
+func py_Traces_IsZero(self py.Object, args py.Tuple) (py.Object, error) {
+	return py.NewBool(self.(pyTraces).IsZero()), nil
+}
+
+func py_Traces_IsBoson(self py.Object, args py.Tuple) (py.Object, error) {
+	return py.NewBool(self.(pyTraces).IsBoson()), nil
+}
+
+func py_Traces_String(self py.Object, args py.Tuple) (py.Object, error) {
+	return py.String(self.(pyTraces).String()), nil
+}
+
+func py_Traces_CharPoly(self py.Object, args py.Tuple) (py.Object, error) {
+	TX := self.(pyTraces).Traces
+	P, err := go2x3.CharPolyOf(TX, len(TX))
+	if err != nil {
+		return nil, py.ExceptionNewf(py.ValueError, "%v", err)
+	}
+	return charPolyTuple(P), nil
+}
+
+func py_Traces_Primitive(self py.Object, args py.Tuple) (py.Object, error) {
+	return pyTraces{self.(pyTraces).Primitive()}, nil
+}
+
+func py_Traces_FromPrimitive(self py.Object, args py.Tuple) (py.Object, error) {
+	return pyTraces{self.(pyTraces).FromPrimitive()}, nil
+}
+
+func py_Traces_Recurrence(self py.Object, args py.Tuple) (py.Object, error) {
+	P, err := go2x3.FindRecurrence(self.(pyTraces).Traces)
+	if err != nil {
+		return nil, py.ExceptionNewf(py.ValueError, "%v", err)
+	}
+	return charPolyTuple(P), nil
+}
+
+func py_Traces_Extend(self py.Object, args py.Tuple) (py.Object, error) {
+	TX := self.(pyTraces).Traces
+	numTraces := 0
+	if err := py.LoadTuple(args, []interface{}{&numTraces}); err != nil {
+		return nil, err
+	}
+	P, err := go2x3.FindRecurrence(TX)
+	if err == nil {
+		TX, err = P.ExtendTraces(TX, numTraces)
+	}
+	if err != nil {
+		return nil, py.ExceptionNewf(py.ValueError, "%v", err)
+	}
+	return pyTraces{TX}, nil
+}